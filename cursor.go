@@ -0,0 +1,159 @@
+package tinyjson
+
+import "strconv"
+
+// Cursor wraps Raw to keep track of where in the document it is, reporting
+// the path of the last token read, like .bars[0].title, for path-aware
+// processing and error messages:
+//
+//	c := tinyjson.Cursor{Raw: data}
+//	for t := c.Next(); t != nil; t = c.Next() {
+//		if t.Kind() == tinyjson.String {
+//			log.Printf("%s = %s", c.Path(), t)
+//		}
+//	}
+//
+// Only reads made through the Cursor's own methods are tracked.
+type Cursor struct {
+	Raw   Raw
+	stack []cursorFrame
+}
+
+type cursorFrame struct {
+	obj     bool
+	wantKey bool  // in objects, whether the next string is a key
+	key     Token // in objects, the current key
+	index   int   // in arrays, the current index, -1 before the first element
+	counted bool  // in arrays, whether ContinueArray has counted the next element
+}
+
+// Next returns the next token like Raw.Next, updating the path.
+func (c *Cursor) Next() Token {
+	t := c.Raw.Next()
+	switch kind := t.Kind(); kind {
+	case EOF, Comma, Colon:
+		break
+	case EndObject, EndArray:
+		if n := len(c.stack); n > 0 {
+			c.stack = c.stack[:n-1]
+			c.completed()
+		}
+	default:
+		if n := len(c.stack); n > 0 && c.stack[n-1].wantKey {
+			c.stack[n-1].key, c.stack[n-1].wantKey = t, false
+			break
+		}
+		c.element()
+		if kind == StartObject || kind == StartArray {
+			c.stack = append(c.stack, cursorFrame{obj: kind == StartObject, wantKey: kind == StartObject, index: -1})
+		} else {
+			c.completed()
+		}
+	}
+	return t
+}
+
+func (c *Cursor) Peek() Kind { return c.Raw.Peek() } // Peek is like Raw.Peek
+
+// StartObject is like Raw.StartObject, updating the path.
+func (c *Cursor) StartObject() Token {
+	if t := c.Next(); t.Kind() != StartObject {
+		panic(unexpected(t))
+	}
+	return c.ContinueObject()
+}
+
+// ContinueObject is like Raw.ContinueObject, updating the path.
+func (c *Cursor) ContinueObject() Token {
+	key := c.Raw.ContinueObject()
+	if n := len(c.stack); key == nil && n > 0 {
+		c.stack = c.stack[:n-1]
+		c.completed()
+	} else if n > 0 {
+		c.stack[n-1].key, c.stack[n-1].wantKey = key, false
+	}
+	return key
+}
+
+// StartArray is like Raw.StartArray, updating the path.
+func (c *Cursor) StartArray() {
+	if t := c.Next(); t.Kind() != StartArray {
+		panic(unexpected(t))
+	}
+}
+
+// ContinueArray is like Raw.ContinueArray, updating the path.
+func (c *Cursor) ContinueArray() bool {
+	more := c.Raw.ContinueArray()
+	if n := len(c.stack); !more && n > 0 {
+		c.stack = c.stack[:n-1]
+		c.completed()
+	} else if n > 0 {
+		c.stack[n-1].index++
+		c.stack[n-1].counted = true
+	}
+	return more
+}
+
+// Skip is like Raw.Skip, updating the path.
+func (c *Cursor) Skip() {
+	c.element()
+	c.Raw.Skip()
+	c.completed()
+}
+
+// Value is like Raw.Value, updating the path.
+func (c *Cursor) Value() any {
+	c.element()
+	v := c.Raw.Value()
+	c.completed()
+	return v
+}
+
+// Path returns the location of the last token read, made of .key and [index]
+// parts, with keys that aren't identifiers quoted, like ["a.b"]. Keys and
+// values in objects share a path, and containers are reported at their own
+// path when opened and closed. The root path is empty.
+func (c *Cursor) Path() string {
+	var buf []byte
+	for _, f := range c.stack {
+		switch {
+		case f.obj && f.key != nil:
+			buf = appendPathKey(buf, f.key.Str())
+		case !f.obj && f.index >= 0:
+			buf = strconv.AppendInt(append(buf, '['), int64(f.index), 10)
+			buf = append(buf, ']')
+		}
+	}
+	return string(buf)
+}
+
+// element notes that the next value is being read in the current container.
+func (c *Cursor) element() {
+	if n := len(c.stack); n > 0 && !c.stack[n-1].obj {
+		if top := &c.stack[n-1]; top.counted {
+			top.counted = false
+		} else {
+			top.index++
+		}
+	}
+}
+
+// completed notes that a value has been read in the current container.
+func (c *Cursor) completed() {
+	if n := len(c.stack); n > 0 && c.stack[n-1].obj {
+		c.stack[n-1].wantKey = true
+	}
+}
+
+func appendPathKey(buf []byte, key string) []byte {
+	for i, c := range []byte(key) {
+		if !(c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+			return append(AppendEscape(append(buf, '['), key), ']')
+		}
+	}
+	if key == "" {
+		return append(buf, `[""]`...)
+	}
+	return append(append(buf, '.'), key...)
+}
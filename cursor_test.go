@@ -0,0 +1,95 @@
+package tinyjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCursorNext(t *testing.T) {
+	c := Cursor{Raw: Raw(`{"a": [1, {"b": null}, []], "c.d": {"": true}, "e": 2}`)}
+	var actual []string
+	for tok := c.Next(); tok != nil; tok = c.Next() {
+		actual = append(actual, tok.Raw()+" "+c.Path())
+	}
+	expected := []string{
+		`{ `,
+		`"a" .a`, `: .a`, `[ .a`,
+		`1 .a[0]`, `, .a[0]`,
+		`{ .a[1]`, `"b" .a[1].b`, `: .a[1].b`, `null .a[1].b`, `} .a[1]`, `, .a[1]`,
+		`[ .a[2]`, `] .a[2]`,
+		`] .a`, `, .a`,
+		`"c.d" ["c.d"]`, `: ["c.d"]`, `{ ["c.d"]`, `"" ["c.d"][""]`, `: ["c.d"][""]`, `true ["c.d"][""]`, `} ["c.d"]`, `, ["c.d"]`,
+		`"e" .e`, `: .e`, `2 .e`,
+		`} `,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** paths:\n%q\nwanted:\n%q", actual, expected)
+	}
+}
+
+func TestCursorNavigation(t *testing.T) {
+	c := Cursor{Raw: Raw(`{"items": [{"id": 1, "tags": ["x", "y"]}, {"id": 2, "skip": {"a": 1}, "v": [3]}], "_n2": 0}`)}
+	var actual []string
+	note := func(what string) {
+		actual = append(actual, what+" "+c.Path())
+	}
+	for key := c.StartObject(); key != nil; key = c.ContinueObject() {
+		note("key")
+		if key.KeyIs("_n2") {
+			c.Value()
+			note("value")
+			continue
+		}
+		for c.StartArray(); c.ContinueArray(); {
+			note("element")
+			for key := c.StartObject(); key != nil; key = c.ContinueObject() {
+				switch key.Str() {
+				case "tags":
+					for c.StartArray(); c.ContinueArray(); {
+						c.Next()
+						note("tag")
+					}
+					note("tags done")
+				case "skip":
+					c.Skip()
+					note("skipped")
+				default:
+					c.Value()
+					note("value")
+				}
+			}
+			note("object done")
+		}
+		note("array done")
+	}
+	note("done")
+	c.Raw.EnsureEOF()
+
+	expected := []string{
+		"key .items",
+		"element .items[0]", "value .items[0].id", "tag .items[0].tags[0]", "tag .items[0].tags[1]", "tags done .items[0].tags", "object done .items[0]",
+		"element .items[1]", "value .items[1].id", "skipped .items[1].skip", "value .items[1].v", "object done .items[1]",
+		"array done .items",
+		"key ._n2", "value ._n2",
+		"done ",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** paths:\n%q\nwanted:\n%q", actual, expected)
+	}
+
+	c = Cursor{Raw: Raw(`[[1, 2], 3]`)}
+	c.StartArray()
+	c.ContinueArray()
+	c.Skip()
+	if c.Next().Kind() != Comma || c.Next().Int() != 3 || c.Path() != "[1]" {
+		t.Errorf("** path after Skip and Next = %s, wanted [1]", c.Path())
+	}
+	if c.Peek() != EndArray || c.Path() != "[1]" {
+		t.Errorf("** Peek changed the path to %s", c.Path())
+	}
+}
+
+func TestCursorPanics(t *testing.T) {
+	ensurePanic(t, func() { c := Cursor{Raw: Raw(`[]`)}; c.StartObject() }, "unexpected JSON: [")
+	ensurePanic(t, func() { c := Cursor{Raw: Raw(`{}`)}; c.StartArray() }, "unexpected JSON: {")
+}
@@ -0,0 +1,535 @@
+package tinyjson
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unsafe"
+)
+
+// Decoder is a Raw with additional per-document settings and state, which
+// a plain []byte cannot carry. Methods defined on Decoder take the settings
+// into account; the embedded Raw can still be passed to existing decoding
+// code as &d.Raw, which then parses it with the default settings.
+//
+//	d := tinyjson.Decoder{Raw: tinyjson.Raw(data), InternKeys: true}
+//	v := d.Value()
+type Decoder struct {
+	Raw
+
+	// InternKeys makes Key and Value return a single shared copy of each
+	// distinct object key. This avoids re-allocating keys that contain escape
+	// sequences, and the returned keys don't keep the input buffer alive.
+	InternKeys bool
+
+	// KeyFunc, if set, normalizes object keys for Key and the Value family of
+	// methods, e.g. folding their case, so that a decoder handling userId,
+	// UserID and userid has a single case for them:
+	//
+	//	d.KeyFunc = func(k []byte) string { return strings.ToLower(string(k)) }
+	//	for key := d.StartObject(); key != nil; key = d.ContinueObject() {
+	//		switch d.Key(key) {
+	//		case "userid":
+	//
+	// It receives the unescaped key, which is only valid during the call and
+	// must not be modified. With InternKeys, it is only called once for each
+	// distinct key.
+	KeyFunc func(key []byte) string
+
+	// Strict rejects all input that RFC 8259 doesn't allow but Raw tolerates
+	// by default for speed:
+	//
+	//   - a UTF-8 byte order mark at the start of the document (Decoder skips it);
+	//   - numbers with a leading plus sign, leading zeros, or a decimal point
+	//     without digits on both sides, like +5, 01, .5 and 5. (Raw reads
+	//     them as 5, 1, 0.5 and 5);
+	//   - escape sequences RFC 8259 doesn't define, like \x (Raw decodes them
+	//     as the escaped character);
+	//   - control characters and invalid UTF-8 in strings (Raw keeps them);
+	//   - missing, leading, trailing and repeated commas in objects and
+	//     arrays, like [1 2], [,1], [1,] and [1,,2] (Raw ignores commas);
+	//
+	// Duplicate object keys are allowed by RFC 8259, and by Strict; use
+	// DuplicateKeys or ValueCanonical to reject them, and MaxDepth to limit
+	// nesting.
+	// SkipContainer doesn't check the commas of what it skips.
+	Strict bool
+
+	// InferColons, if set, accepts object members without a colon between
+	// the key and the value, like {"a" 1}, for scraping sloppy data dumps.
+	// Commas are optional even without it: Raw reads [1 2 3] as [1, 2, 3]
+	// and {"a": 1 "b": 2} as {"a": 1, "b": 2}. A missing key or value is
+	// still an error, and Strict overrides InferColons.
+	InferColons bool
+
+	// ValidUTF8, if set, rejects strings (including object keys) that aren't
+	// valid UTF-8 as soon as they are read, like Strict does, but without the
+	// other checks of Strict. Multibyte sequences cut short, like a lead byte
+	// right before the closing quote or a backslash, are reported as truncated.
+	ValidUTF8 bool
+
+	// OnError, if set, is called instead of panicking on invalid JSON, with
+	// the number of input bytes consumed so far and the panic message. The
+	// failing method returns a zero value, and the rest of the input is
+	// discarded, so that decoding loops in progress terminate; further errors
+	// are not reported.
+	OnError func(offset int, msg string)
+
+	// MaxDepth, if positive, limits how deeply objects and arrays may nest
+	// within Value, ValueCanonical, ValueOrdered, ValueIter, Visit, Skip and
+	// NestingDepth. Exceeding it is reported like invalid JSON, so combine it
+	// with OnError to get an error instead of a panic. Zero means no limit.
+	MaxDepth int
+
+	// Trace, if set, receives a line for every token read, with its offset
+	// and the number of bytes left after it, like
+	//
+	//	12: "name" (40 left)
+	//
+	// to find where a hand-written decoder gets out of step with the input.
+	// Write errors are ignored. Meant for debugging only.
+	Trace io.Writer
+
+	// DuplicateKeys chooses which of the values of a repeated object key
+	// Value, ValueIter and CaptureExtra keep. Some specs mandate the first
+	// one, while Go's encoding/json and JavaScript keep the last one, which
+	// is the default.
+	DuplicateKeys DuplicateKeyPolicy
+
+	// Pool, if set, supplies recycled maps and slices to Value and ValueIter.
+	Pool *Pool
+
+	// NumberParser, if set, converts numbers for Scalar and the Value family
+	// of methods instead of Token.Float, e.g. into a decimal type for money
+	// amounts. An error is reported like a type mismatch. Note that
+	// AppendValue doesn't know how to encode custom types.
+	NumberParser func(raw []byte) (any, error)
+
+	// NullValue, if set, is returned by Scalar and the Value family of
+	// methods for JSON null instead of nil, e.g. to tell {"x": null} from {}.
+	NullValue any
+
+	keys    map[string]string
+	pos     int
+	depth   int
+	failed  bool
+	started bool // whether a leading byte order mark has been handled
+	first   bool // in Strict mode, whether the last token opened an object or array
+}
+
+// DuplicateKeyPolicy is the handling of repeated object keys, see
+// Decoder.DuplicateKeys.
+type DuplicateKeyPolicy byte
+
+const (
+	KeepLast  DuplicateKeyPolicy = iota // keep the last value, like Raw does
+	KeepFirst                           // keep the first value, skipping the later ones
+	Error                               // panic with "invalid JSON: duplicate key ..."
+)
+
+// Reset starts decoding data, keeping the settings and the reusable state,
+// like interned keys, so that a single Decoder (e.g. one per goroutine) can
+// decode many documents without allocating anew. Errors are reported to
+// OnError again after Reset.
+func (d *Decoder) Reset(data []byte) {
+	d.Raw = Raw(data)
+	d.pos, d.depth, d.failed, d.first, d.started = 0, 0, false, false, false
+}
+
+func (d *Decoder) Next() Token           { defer d.catch(); return d.Raw.next(d) }           // Next is like Raw.Next, honoring the settings
+func (d *Decoder) Peek() Kind            { defer d.catch(); return d.Raw.peek(d) }           // Peek is like Raw.Peek, honoring the settings
+func (d *Decoder) StartObject() Token    { defer d.catch(); return d.Raw.startObject(d) }    // StartObject is like Raw.StartObject, honoring the settings
+func (d *Decoder) ContinueObject() Token { defer d.catch(); return d.Raw.continueObject(d) } // ContinueObject is like Raw.ContinueObject, honoring the settings
+func (d *Decoder) StartArray()           { defer d.catch(); d.Raw.startArray(d) }            // StartArray is like Raw.StartArray, honoring the settings
+func (d *Decoder) ContinueArray() bool   { defer d.catch(); return d.Raw.continueArray(d) }  // ContinueArray is like Raw.ContinueArray, honoring the settings
+func (d *Decoder) Null() bool            { defer d.catch(); return d.Raw.null(d) }           // Null is like Raw.Null, honoring the settings
+func (d *Decoder) NullOrEOF() bool       { defer d.catch(); return d.Raw.nullOrEOF(d) }      // NullOrEOF is like Raw.NullOrEOF, honoring the settings
+func (d *Decoder) Skip()                 { defer d.catch(); d.Raw.skip(d) }                  // Skip is like Raw.Skip, honoring the settings
+func (d *Decoder) SkipN() int            { defer d.catch(); return d.Raw.skipN(d) }          // SkipN is like Raw.SkipN, honoring the settings
+func (d *Decoder) EnsureEOF()            { defer d.catch(); d.Raw.ensureEOF(d) }             // EnsureEOF is like Raw.EnsureEOF, honoring the settings
+func (d *Decoder) SkipContainer()        { defer d.catch(); d.Raw.skipContainer(d) }         // SkipContainer is like Raw.SkipContainer, honoring the settings
+
+// NullString is like Raw.NullString, honoring the settings.
+func (d *Decoder) NullString() (string, bool) {
+	defer d.catch()
+	return nullable(&d.Raw, d, Token.Str)
+}
+
+// NullInt64 is like Raw.NullInt64, honoring the settings.
+func (d *Decoder) NullInt64() (int64, bool) {
+	defer d.catch()
+	return nullable(&d.Raw, d, Token.Int64)
+}
+
+// NullFloat64 is like Raw.NullFloat64, honoring the settings.
+func (d *Decoder) NullFloat64() (float64, bool) {
+	defer d.catch()
+	return nullable(&d.Raw, d, Token.Float)
+}
+
+// NullBool is like Raw.NullBool, honoring the settings.
+func (d *Decoder) NullBool() (bool, bool) {
+	defer d.catch()
+	return nullable(&d.Raw, d, Token.Bool)
+}
+
+// ArrayIndex is like Raw.ArrayIndex, honoring the settings.
+func (d *Decoder) ArrayIndex(i int) bool {
+	defer d.catch()
+	return d.Raw.arrayElement(d, i)
+}
+
+// NextComplete is like Raw.NextComplete, honoring the settings.
+func (d *Decoder) NextComplete() (Token, bool) {
+	defer d.catch()
+	return d.Raw.nextComplete(d)
+}
+
+// PeekKind is like Raw.PeekKind, honoring the settings.
+func (d *Decoder) PeekKind() Kind {
+	defer d.catch()
+	d.Raw.skipBOM(d)
+	kind, remainder := peekNextTokenKind(d.Raw)
+	if kind == EOF && remainder != nil {
+		panic(invalidByte(remainder))
+	}
+	return kind
+}
+
+// AtEOF is like Raw.AtEOF, honoring the settings.
+func (d *Decoder) AtEOF() bool {
+	defer d.catch()
+	d.Raw.skipBOM(d)
+	return skipWhitespace(d.Raw) == len(d.Raw)
+}
+
+// More is like Raw.More, honoring the settings.
+func (d *Decoder) More() bool {
+	return !d.AtEOF()
+}
+
+// NestingDepth is like Raw.NestingDepth, honoring the settings.
+func (d *Decoder) NestingDepth() (depth int) {
+	defer d.catch()
+	d.lookAhead(func(ahead *Decoder) { depth = ahead.Raw.nestingDepth(ahead) })
+	return depth
+}
+
+// IsEmptyObject is like Raw.IsEmptyObject, honoring the settings.
+func (d *Decoder) IsEmptyObject() bool {
+	defer d.catch()
+	d.Raw.skipBOM(d)
+	return d.Raw.IsEmptyObject()
+}
+
+// IsEmptyArray is like Raw.IsEmptyArray, honoring the settings.
+func (d *Decoder) IsEmptyArray() bool {
+	defer d.catch()
+	d.Raw.skipBOM(d)
+	return d.Raw.IsEmptyArray()
+}
+
+// Fork is like Raw.Fork, returning a copy of d, with the same settings, that
+// advances independently of it; continue from the fork with *d = fork.
+func (d *Decoder) Fork() Decoder {
+	return *d
+}
+
+// Pointer is like Raw.Pointer, honoring the settings in everything it scans.
+// Like all lookups, it matches keys as written, without KeyFunc.
+func (d *Decoder) Pointer(ptr string) (t Token, ok bool) {
+	defer d.catch()
+	d.lookAhead(func(ahead *Decoder) { t, ok = ahead.Raw.pointer(ahead, ptr) })
+	return t, ok
+}
+
+// PointerMany is like Raw.PointerMany, honoring the settings like Pointer.
+func (d *Decoder) PointerMany(ptrs []string) (result []Token) {
+	defer d.catch()
+	d.lookAhead(func(ahead *Decoder) { result = ahead.Raw.pointerMany(ahead, ptrs) })
+	return result
+}
+
+// Get is like Raw.Get, honoring the settings like Pointer. The result has
+// been checked with the settings, but decodes with the default ones.
+func (d *Decoder) Get(key string) (l Lookup) {
+	defer d.catch()
+	d.lookAhead(func(ahead *Decoder) { l = ahead.Raw.get(ahead, key) })
+	return l
+}
+
+// At is like Get for element i of an array.
+func (d *Decoder) At(i int) (l Lookup) {
+	defer d.catch()
+	d.lookAhead(func(ahead *Decoder) { l = ahead.Raw.at(ahead, i) })
+	return l
+}
+
+// Tokens is like Raw.Tokens, honoring the settings.
+func (d *Decoder) Tokens() func(yield func(Kind, Token) bool) {
+	tokens := d.Raw.tokens(d)
+	return func(yield func(Kind, Token) bool) {
+		defer d.catch()
+		tokens(yield)
+	}
+}
+
+func (d *Decoder) Str() string    { defer d.catch(); return d.Raw.next(d).Str() }    // Str returns .Next().Str()
+func (d *Decoder) Int() int       { defer d.catch(); return d.Raw.next(d).Int() }    // Int returns .Next().Int()
+func (d *Decoder) Int64() int64   { defer d.catch(); return d.Raw.next(d).Int64() }  // Int64 returns .Next().Int64()
+func (d *Decoder) Uint64() uint64 { defer d.catch(); return d.Raw.next(d).Uint64() } // Uint64 returns .Next().Uint64()
+func (d *Decoder) Float() float64 { defer d.catch(); return d.Raw.next(d).Float() }  // Float returns .Next().Float()
+func (d *Decoder) Bool() bool     { defer d.catch(); return d.Raw.next(d).Bool() }   // Bool returns .Next().Bool()
+
+// IntExact returns .Next().IntExact()
+func (d *Decoder) IntExact() (int64, bool) {
+	defer d.catch()
+	return d.Raw.next(d).IntExact()
+}
+
+func (d *Decoder) Int8() int8     { defer d.catch(); return d.Raw.next(d).Int8() }   // Int8 returns .Next().Int8()
+func (d *Decoder) Int16() int16   { defer d.catch(); return d.Raw.next(d).Int16() }  // Int16 returns .Next().Int16()
+func (d *Decoder) Int32() int32   { defer d.catch(); return d.Raw.next(d).Int32() }  // Int32 returns .Next().Int32()
+func (d *Decoder) Uint8() uint8   { defer d.catch(); return d.Raw.next(d).Uint8() }  // Uint8 returns .Next().Uint8()
+func (d *Decoder) Uint16() uint16 { defer d.catch(); return d.Raw.next(d).Uint16() } // Uint16 returns .Next().Uint16()
+func (d *Decoder) Uint32() uint32 { defer d.catch(); return d.Raw.next(d).Uint32() } // Uint32 returns .Next().Uint32()
+
+func (d *Decoder) FloatLenient() float64   { defer d.catch(); return d.Raw.next(d).FloatLenient() }    // FloatLenient returns .Next().FloatLenient()
+func (d *Decoder) FloatFast() float64      { defer d.catch(); return d.Raw.next(d).FloatFast() }       // FloatFast returns .Next().FloatFast()
+func (d *Decoder) NumberCanonical() string { defer d.catch(); return d.Raw.next(d).NumberCanonical() } // NumberCanonical returns .Next().NumberCanonical()
+
+// Scalar returns .Next().Scalar(), honoring NumberParser and NullValue.
+func (d *Decoder) Scalar() any {
+	defer d.catch()
+	return d.scalar(d.Raw.next(d))
+}
+
+// Enum returns .Next().Enum(allowed...)
+func (d *Decoder) Enum(allowed ...string) string {
+	defer d.catch()
+	return d.Raw.next(d).Enum(allowed...)
+}
+
+// SafeStr returns .Next().SafeStr()
+func (d *Decoder) SafeStr() string {
+	defer d.catch()
+	return d.Raw.next(d).SafeStr()
+}
+
+// StrTrimmed returns .Next().StrTrimmed()
+func (d *Decoder) StrTrimmed() string {
+	defer d.catch()
+	return d.Raw.next(d).StrTrimmed()
+}
+
+// EnumFold returns .Next().EnumFold(allowed...)
+func (d *Decoder) EnumFold(allowed ...string) string {
+	defer d.catch()
+	return d.Raw.next(d).EnumFold(allowed...)
+}
+
+// Key returns key.Str(), normalized by KeyFunc and interned if InternKeys is set.
+func (d *Decoder) Key(key Token) string {
+	defer d.catch()
+	return d.key(key)
+}
+
+// Value is like Raw.Value, but produces object keys like Key.
+func (d *Decoder) Value() any {
+	defer d.catch()
+	return d.Raw.value(d)
+}
+
+// ValueTyped is like Raw.ValueTyped, but produces object keys like Key. It
+// parses numbers with IntOrFloat, whatever NumberParser is set to.
+func (d *Decoder) ValueTyped() any {
+	defer d.catch()
+	parser := d.NumberParser
+	defer func() { d.NumberParser = parser }()
+	d.NumberParser = IntOrFloat
+	return d.Raw.value(d)
+}
+
+// CaptureExtra is like Raw.CaptureExtra, but produces keys and values like Value.
+func (d *Decoder) CaptureExtra(dst map[string]any, key Token) {
+	defer d.catch()
+	d.Raw.captureExtra(d, dst, key)
+}
+
+// ValueAs is like Raw.ValueAs, but produces values like Value.
+func (d *Decoder) ValueAs(kind Kind) any {
+	defer d.catch()
+	return d.Raw.valueAs(d, kind)
+}
+
+// ValueCanonical is like Raw.ValueCanonical, but produces object keys like Key.
+func (d *Decoder) ValueCanonical() any {
+	defer d.catch()
+	return d.Raw.valueCanonical(d)
+}
+
+// ValueOrdered is like Raw.ValueOrdered, but produces object keys like Key.
+func (d *Decoder) ValueOrdered() any {
+	defer d.catch()
+	return d.Raw.valueOrdered(d)
+}
+
+// ValueIter is like Raw.ValueIter, but produces object keys like Key.
+func (d *Decoder) ValueIter() any {
+	defer d.catch()
+	return d.Raw.valueIter(d)
+}
+
+// NextSeq is like Raw.NextSeq, but produces values like Value.
+func (d *Decoder) NextSeq() (any, bool) {
+	defer d.catch()
+	return d.Raw.nextSeq(d)
+}
+
+// Visit is like Raw.Visit, honoring the settings.
+func (d *Decoder) Visit(v Visitor) {
+	defer d.catch()
+	d.Raw.visit(d, v)
+}
+
+func (d *Decoder) key(key Token) string {
+	if d == nil || !d.InternKeys {
+		return d.normalizeKey(key)
+	}
+	if s, ok := d.keys[string(key)]; ok {
+		return s
+	}
+	s := strings.Clone(d.normalizeKey(key))
+	if d.keys == nil {
+		d.keys = make(map[string]string)
+	}
+	d.keys[string(key)] = s
+	return s
+}
+
+// member reports whether the value of key k should be stored into obj, which
+// is false for a duplicate key that KeepFirst ignores.
+func (d *Decoder) member(obj map[string]any, k string, key Token) bool {
+	if d == nil || d.DuplicateKeys == KeepLast {
+		return true
+	}
+	if _, dup := obj[k]; !dup {
+		return true
+	}
+	if d.DuplicateKeys == Error {
+		panic("invalid JSON: duplicate key " + key.Raw())
+	}
+	return false
+}
+
+func (d *Decoder) normalizeKey(key Token) string {
+	s := key.Str()
+	if d == nil || d.KeyFunc == nil {
+		return s
+	}
+	return d.KeyFunc(unsafe.Slice(unsafe.StringData(s), len(s)))
+}
+
+func (d *Decoder) scalar(t Token) any {
+	if d != nil && d.NullValue != nil && t.Kind() == Null {
+		return d.NullValue
+	}
+	if d != nil && d.NumberParser != nil && t.Kind() == Number {
+		v, err := d.NumberParser(t)
+		if err != nil {
+			panic("unexpected JSON: " + t.Raw() + ": " + err.Error())
+		}
+		return v
+	}
+	return t.Scalar()
+}
+
+func (d *Decoder) pool() *Pool {
+	if d == nil {
+		return nil
+	}
+	return d.Pool
+}
+
+// skipBOM skips a UTF-8 byte order mark at the start of the document, or
+// rejects it in Strict mode. Waits while the data is a prefix of one, which
+// more data passed to Reset may complete.
+func (raw *Raw) skipBOM(d *Decoder) {
+	if d == nil || d.started || isProperPrefix(*raw, bom) {
+		return
+	}
+	d.started = true
+	if hasBOM(*raw) {
+		if d.Strict {
+			panic("invalid JSON: unexpected byte order mark")
+		}
+		*raw = (*raw)[len(bom):]
+		d.advance(len(bom))
+	}
+}
+
+func (d *Decoder) strict() bool {
+	return d != nil && d.Strict
+}
+
+func (d *Decoder) advance(n int) {
+	if d != nil {
+		d.pos += n
+	}
+}
+
+// lookAhead calls f with a copy of d to decode without consuming anything,
+// reporting errors at the offset where f failed.
+func (d *Decoder) lookAhead(f func(ahead *Decoder)) {
+	ahead := *d
+	defer func() {
+		if e := recover(); e != nil {
+			d.pos = ahead.pos
+			panic(e)
+		}
+	}()
+	f(&ahead)
+}
+
+func (d *Decoder) enter() {
+	if d != nil && d.MaxDepth > 0 {
+		d.depth++
+		if d.depth > d.MaxDepth {
+			panic("invalid JSON: nesting exceeds maximum depth")
+		}
+	}
+}
+
+func (d *Decoder) leave() {
+	if d != nil && d.MaxDepth > 0 {
+		d.depth--
+	}
+}
+
+func (d *Decoder) trace(t Token, left int) {
+	line := strconv.AppendInt(make([]byte, 0, 32+len(t)), int64(d.pos-len(t)), 10)
+	line = append(line, ": "...)
+	if t == nil {
+		line = append(line, "EOF"...)
+	}
+	line = append(line, t...)
+	line = append(line, " ("...)
+	line = strconv.AppendInt(line, int64(left), 10)
+	line = append(line, " left)\n"...)
+	d.Trace.Write(line)
+}
+
+func (d *Decoder) catch() {
+	if d.OnError == nil {
+		return
+	}
+	if e := recover(); e != nil {
+		msg := panicMessage(e) // re-panics on bugs, e.g. in NumberParser or KeyFunc
+		d.Raw = nil
+		d.depth = 0
+		if !d.failed {
+			d.failed = true
+			d.OnError(d.pos, msg)
+		}
+	}
+}
@@ -0,0 +1,731 @@
+package tinyjson
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestDecoderInternKeys(t *testing.T) {
+	input := `[{"id":1,"name":"a"},{"id":2,"name":"b"}]`
+	d := Decoder{Raw: Raw(input), InternKeys: true}
+	actual := d.Value()
+	expected := []any{map[string]any{"id": 1.0, "name": "a"}, map[string]any{"id": 2.0, "name": "b"}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Fatalf("** Decoder.Value() = %v, wanted %v", actual, expected)
+	}
+
+	var names []string
+	for _, obj := range actual.([]any) {
+		for k := range obj.(map[string]any) {
+			names = append(names, k)
+		}
+	}
+	for _, k := range names {
+		if isWithin(k, input) {
+			t.Errorf("** interned key %q aliases the input", k)
+		}
+	}
+
+	a, b := d.Key(Token(`"name"`)), d.Key(Token(`"name"`))
+	if a != "name" || unsafe.StringData(a) != unsafe.StringData(b) {
+		t.Errorf("** Decoder.Key() returned distinct copies of %q", a)
+	}
+}
+
+func TestDecoderWithoutInterning(t *testing.T) {
+	d := Decoder{Raw: Raw(`{"a":[1]}`)}
+	actual := d.Value()
+	expected := map[string]any{"a": []any{1.0}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** Decoder.Value() = %v, wanted %v", actual, expected)
+	}
+	if k := d.Key(Token(`"x"`)); k != "x" {
+		t.Errorf("** Decoder.Key() = %q, wanted %q", k, "x")
+	}
+}
+
+func TestDecoderKeyFunc(t *testing.T) {
+	input := `[{"userId":1,"User\u0049D":2,"userid":3}]`
+	var seen []string
+	for _, intern := range []bool{false, true} {
+		seen = nil
+		d := Decoder{Raw: Raw(input), InternKeys: intern}
+		d.KeyFunc = func(k []byte) string {
+			seen = append(seen, string(k))
+			return strings.ToLower(string(k))
+		}
+		var ids []int
+		d.StartArray()
+		for d.ContinueArray() {
+			for key := d.StartObject(); key != nil; key = d.ContinueObject() {
+				switch d.Key(key) {
+				case "userid":
+					ids = append(ids, d.Int())
+				default:
+					t.Errorf("** unexpected key %q", d.Key(key))
+					d.Skip()
+				}
+			}
+		}
+		if !reflect.DeepEqual(ids, []int{1, 2, 3}) {
+			t.Errorf("** InternKeys=%v: decoded %v, wanted all three ids", intern, ids)
+		}
+		if expected := []string{"userId", "UserID", "userid"}; !reflect.DeepEqual(seen, expected) {
+			t.Errorf("** InternKeys=%v: KeyFunc got %q, wanted %q", intern, seen, expected)
+		}
+
+		d.Raw = Raw(`{"A":{"B":1},"a":{"b":2}}`)
+		seen = nil
+		actual := d.Value()
+		expected := map[string]any{"a": map[string]any{"b": 2.0}}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("** InternKeys=%v: Value() = %v, wanted %v", intern, actual, expected)
+		}
+		if intern {
+			d.Raw = Raw(`{"A":0}`)
+			d.Value()
+			if len(seen) != 4 {
+				t.Errorf("** KeyFunc called %d times, wanted once per distinct key", len(seen))
+			}
+		}
+	}
+}
+
+func BenchmarkDecodeInternedKeys(b *testing.B) {
+	orig := Raw(`[{"type":"Feature","id":1},{"type":"Feature","id":2},{"type":"Feature","id":3}]`)
+	d := Decoder{InternKeys: true}
+	for i := 0; i < b.N; i++ {
+		d.Raw = orig
+		d.Value()
+	}
+}
+
+func TestDecoderReset(t *testing.T) {
+	var errs []string
+	d := Decoder{InternKeys: true, MaxDepth: 2, Strict: true, OnError: func(offset int, msg string) {
+		errs = append(errs, fmt.Sprintf("%d: %s", offset, msg))
+	}}
+
+	d.Reset([]byte(`{"name": [[1]]}`))
+	d.Value()
+	d.Reset([]byte(`{"name": [1}`))
+	d.Value()
+	d.Reset([]byte(`{"name": "x"}`))
+	a := d.Value().(map[string]any)
+	d.Reset([]byte(`{"name": "y"}`))
+	b := d.Value().(map[string]any)
+
+	expected := []string{"11: invalid JSON: nesting exceeds maximum depth", "11: invalid JSON"}
+	if !reflect.DeepEqual(errs, expected) {
+		t.Errorf("** errors reported %q, wanted %q", errs, expected)
+	}
+	var ka, kb string
+	for k := range a {
+		ka = k
+	}
+	for k := range b {
+		kb = k
+	}
+	if ka != "name" || unsafe.StringData(ka) != unsafe.StringData(kb) {
+		t.Errorf("** interned keys not kept across Reset")
+	}
+	if a["name"] != "x" || b["name"] != "y" {
+		t.Errorf("** decoded %v and %v after Reset", a, b)
+	}
+}
+
+func BenchmarkDecoderReset(b *testing.B) {
+	data := []byte(benchmarkValueInput)
+	d := Decoder{InternKeys: true, Pool: new(Pool)}
+	for i := 0; i < b.N; i++ {
+		d.Reset(data)
+		d.Pool.Release(d.Value())
+	}
+}
+
+func isWithin(s, buf string) bool {
+	p, start := uintptr(unsafe.Pointer(unsafe.StringData(s))), uintptr(unsafe.Pointer(unsafe.StringData(buf)))
+	return p >= start && p < start+uintptr(len(buf))
+}
+
+func TestDecoderMethods(t *testing.T) {
+	d := Decoder{Raw: Raw(`{"s":"x", "i":-1, "i64":2, "u64":3, "f":1.5, "fs":"2.5", "ff":0.125, "nc":1.0, "sized":[-8, -16, -32, 8, 16, 32], "b":true, "e":"on", "ef":"OFF", "st":" x ", "ss":"y", "n":null, "o":{}, "a":[1,[2]], "skip":{"x":[]}, "sc":{"x":1, "y":[{}]}, "v":[{"k":"v"}], "vc":{"x":[1]}, "vo":{"b":1,"a":2}, "va":{"x":null}, "pk":[1], "nd":[[1],{}], "vt":[1, 1.5], "ptr":{"x":[5]}, "get":{"x":[6, 7]}, "eo":{}, "ea":[ ], "fk":[1]}`)}
+	for key := d.StartObject(); key != nil; key = d.ContinueObject() {
+		var actual, expected any
+		switch key.Str() {
+		case "s":
+			actual, expected = d.Str(), "x"
+		case "i":
+			actual, expected = d.Int(), -1
+		case "i64":
+			actual, expected = d.Int64(), int64(2)
+		case "u64":
+			actual, expected = d.Uint64(), uint64(3)
+		case "f":
+			actual, expected = d.Float(), 1.5
+		case "fs":
+			actual, expected = d.FloatLenient(), 2.5
+		case "ff":
+			actual, expected = d.FloatFast(), 0.125
+		case "sized":
+			var values []any
+			d.StartArray()
+			for _, f := range []func() any{
+				func() any { return d.Int8() },
+				func() any { return d.Int16() },
+				func() any { return d.Int32() },
+				func() any { return d.Uint8() },
+				func() any { return d.Uint16() },
+				func() any { return d.Uint32() },
+			} {
+				d.ContinueArray()
+				values = append(values, f())
+			}
+			d.ContinueArray()
+			actual, expected = values, []any{int8(-8), int16(-16), int32(-32), uint8(8), uint16(16), uint32(32)}
+		case "nc":
+			actual, expected = d.NumberCanonical(), "1"
+		case "b":
+			actual, expected = d.Bool(), true
+		case "e":
+			actual, expected = d.Enum("on", "off"), "on"
+		case "ss":
+			actual, expected = d.SafeStr(), "y"
+		case "st":
+			actual, expected = d.StrTrimmed(), "x"
+		case "ef":
+			actual, expected = d.EnumFold("on", "off"), "off"
+		case "n":
+			actual, expected = d.Null(), true
+		case "o":
+			actual, expected = d.StartObject(), Token(nil)
+		case "a":
+			var items []Kind
+			for d.StartArray(); d.ContinueArray(); {
+				items = append(items, d.Peek())
+				d.Skip()
+			}
+			actual, expected = items, []Kind{Number, StartArray}
+		case "skip":
+			actual, expected = d.SkipN(), 8
+		case "sc":
+			d.StartObject()
+			d.Int()
+			d.SkipContainer()
+			actual, expected = d.Peek(), Comma
+		case "v":
+			actual, expected = d.Value(), []any{map[string]any{"k": "v"}}
+		case "vc":
+			actual, expected = d.ValueCanonical(), map[string]any{"x": []any{1.0}}
+		case "vo":
+			actual, expected = d.ValueOrdered(), []KV{{"b", 1.0}, {"a", 2.0}}
+		case "va":
+			actual, expected = d.ValueAs(StartObject), map[string]any{"x": nil}
+		case "pk":
+			kind := d.PeekKind()
+			d.Skip()
+			actual, expected = kind, StartArray
+		case "nd":
+			depth := d.NestingDepth()
+			d.Skip()
+			actual, expected = depth, 2
+		case "vt":
+			actual, expected = d.ValueTyped(), []any{int64(1), 1.5}
+		case "ptr":
+			tok, ok := d.Pointer("/x/0")
+			many := d.PointerMany([]string{"/x", "/y"})
+			d.Skip()
+			actual, expected = []any{tok, ok, many}, []any{Token("5"), true, []Token{Token("[5]"), nil}}
+		case "get":
+			x, second := d.Get("x").Raw, d.At(0).Missing()
+			d.Skip()
+			actual, expected = []any{x, second}, []any{Raw("[6, 7]"), true}
+		case "eo":
+			empty := d.IsEmptyObject() && !d.IsEmptyArray()
+			d.Skip()
+			actual, expected = empty, true
+		case "ea":
+			empty := d.IsEmptyArray() && !d.IsEmptyObject()
+			d.Skip()
+			actual, expected = empty, true
+		case "fk":
+			fork := d.Fork()
+			fork.StartArray()
+			first := d.Peek()
+			d = fork
+			actual, expected = []any{first, d.Int(), d.ContinueArray()}, []any{StartArray, 1, false}
+		default:
+			t.Fatalf("** unexpected key %s", key)
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("** %s = %v, wanted %v", key, actual, expected)
+		}
+	}
+	d.EnsureEOF()
+	if !d.AtEOF() || d.More() {
+		t.Errorf("** AtEOF() = %v, More() = %v after EnsureEOF", d.AtEOF(), d.More())
+	}
+	d.Tokens()(func(kind Kind, token Token) bool {
+		t.Errorf("** Tokens() at EOF yielded %s", token)
+		return true
+	})
+	if tok := d.Next(); tok != nil {
+		t.Errorf("** Next() at EOF = %s, wanted nil", tok)
+	}
+}
+
+func TestDecoderStrictNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{`0`, 0},
+		{`-0`, 0},
+		{`-12`, -12},
+		{`0.5E-3`, 0.5e-3},
+		{`1.5e+10`, 1.5e10},
+		{`10e2`, 10e2},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			d := Decoder{Raw: Raw(test.input), Strict: true}
+			if actual := d.Float(); actual != test.expected {
+				t.Errorf("** Decoder.Float(%s) = %g, wanted %g", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestDecoderTrace(t *testing.T) {
+	var buf strings.Builder
+	d := Decoder{Raw: Raw(`{"a": [1, 2], "b": {"c": null}} `), Trace: &buf}
+	for key := d.StartObject(); key != nil; key = d.ContinueObject() {
+		if key.Str() == "a" {
+			d.Skip()
+		} else {
+			d.Value()
+		}
+	}
+	d.Next()
+
+	expected := `0: { (31 left)
+1: "a" (28 left)
+4: : (27 left)
+6: [ (25 left)
+7: 1 (24 left)
+8: , (23 left)
+10: 2 (21 left)
+11: ] (20 left)
+12: , (19 left)
+14: "b" (15 left)
+17: : (14 left)
+19: { (12 left)
+20: "c" (9 left)
+23: : (8 left)
+25: null (3 left)
+29: } (2 left)
+30: } (1 left)
+32: EOF (0 left)
+`
+	if actual := buf.String(); actual != expected {
+		t.Errorf("** trace:\n%s\nwanted:\n%s", actual, expected)
+	}
+}
+
+func TestDecoderInferColons(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected any // panic message if a string
+	}{
+		{`missing colon`, `{"a" 1}`, map[string]any{"a": 1.0}},
+		{`mixed`, `{"a": 1, "b" [2 3] "c" {"d" null}}`, map[string]any{"a": 1.0, "b": []any{2.0, 3.0}, "c": map[string]any{"d": nil}}},
+		{`string value`, `{"a" "b"}`, map[string]any{"a": "b"}},
+		{`missing value`, `{"a"}`, "invalid JSON"},
+		{`missing key`, `{: 1}`, "invalid JSON"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Decoder{Raw: Raw(test.input), InferColons: true}
+			var actual any
+			if msg := capturePanic(func() { actual = d.Value() }); msg != nil {
+				actual = msg
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** Value(%s) = %v, wanted %v", test.input, actual, test.expected)
+			}
+		})
+	}
+
+	d := Decoder{Raw: Raw(`{"a" 1}`), InferColons: true, Strict: true}
+	ensurePanic(t, func() { d.Value() }, "invalid JSON")
+	ensurePanic(t, func() { raw(`{"a" 1}`).Value() }, "invalid JSON")
+}
+
+func TestDecoderStrict(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{`leading byte order mark`, "\xEF\xBB\xBF{}", "invalid JSON: unexpected byte order mark"},
+		{`byte order mark between tokens`, "[1, \xEF\xBB\xBF2]", "invalid JSON: unexpected byte order mark"},
+		{`leading plus`, `+5`, "invalid JSON: leading plus sign in number +5"},
+		{`leading zero`, `[01]`, "invalid JSON: leading zero in number 01"},
+		{`leading zero in negative`, `-01`, "invalid JSON: leading zero in number -01"},
+		{`leading decimal point`, `.5`, "invalid JSON: decimal point without preceding digits in number .5"},
+		{`negative leading decimal point`, `-.5`, "invalid JSON: decimal point without preceding digits in number -.5"},
+		{`trailing decimal point`, `5.`, "invalid JSON: decimal point without following digits in number 5."},
+		{`decimal point before exponent`, `5.e3`, "invalid JSON: decimal point without following digits in number 5.e3"},
+		{`bare minus`, `-`, "invalid JSON: missing digits in number -"},
+		{`minus before exponent`, `-e3`, "invalid JSON: missing digits in number -e3"},
+		{`multiple decimal points`, `1.2.3`, "invalid JSON: multiple decimal points in number 1.2.3"},
+		{`adjacent decimal points`, `1..2`, "invalid JSON: multiple decimal points in number 1..2"},
+		{`exponent without digits`, `1e`, "invalid JSON: exponent without digits in number 1e"},
+		{`signed exponent without digits`, `1E+`, "invalid JSON: exponent without digits in number 1E+"},
+		{`decimal point in exponent`, `1e5.5`, "invalid JSON: decimal point in exponent in number 1e5.5"},
+		{`multiple exponents`, `1e5e5`, "invalid JSON: multiple exponents in number 1e5e5"},
+		{`misplaced sign`, `1-2`, "invalid JSON: misplaced sign in number 1-2"},
+		{`misplaced sign after zero`, `0+`, "invalid JSON: misplaced sign in number 0+"},
+		{`unknown escape`, `["ok\n", "a\x"]`, `invalid JSON: invalid escape sequence \x in string "a\x"`},
+		{`invalid unicode escape`, `["\u00e9\uXYZW"]`, `invalid JSON: invalid escape sequence \u in string "\u00e9\uXYZW"`},
+		{`control character`, "\"a\x01\"", `invalid JSON: control character in string "\"a\x01\""`},
+		{`raw newline`, "[\"a\nb\"]", `invalid JSON: control character in string "\"a\nb\""`},
+		{`raw newline in key`, "{\"a\nb\": 1}", `invalid JSON: control character in object key "\"a\nb\""`},
+		{`tab in later key`, "{\"a\": \"\\t\", \"b\tc\": 1}", `invalid JSON: control character in object key "\"b\tc\""`},
+		{`invalid escape in key`, `{"\u00e9\uXYZW": 1}`, `invalid JSON: invalid escape sequence \u in object key "\u00e9\uXYZW"`},
+		{`unknown escape in nested key`, `[{"a": {"\x": 1}}]`, `invalid JSON: invalid escape sequence \x in object key "\x"`},
+		{`invalid UTF-8 in key`, "{\"\xff\": 1}", `invalid JSON: invalid UTF-8 in object key "\"\xff\""`},
+		{`invalid UTF-8`, "[\"\xff\"]", `invalid JSON: invalid UTF-8 in string "\"\xff\""`},
+		{`truncated UTF-8`, "[\"\xe2\x82\"]", `invalid JSON: truncated UTF-8 sequence in string "\"\xe2\x82\""`},
+		{`missing comma in array`, `[1 2]`, "invalid JSON: missing comma"},
+		{`missing comma in object`, `{"a": 1 "b": 2}`, "invalid JSON: missing comma"},
+		{`missing comma after nested`, `{"a": [1], "b": {"c": 2} "d": 3}`, "invalid JSON: missing comma"},
+		{`leading comma in array`, `[,1]`, "invalid JSON: unexpected comma"},
+		{`leading comma in object`, `{,"a": 1}`, "invalid JSON: unexpected comma"},
+		{`trailing comma in array`, `[1,]`, "invalid JSON: expected value after comma"},
+		{`double comma in array`, `[1,,2]`, "invalid JSON: expected value after comma"},
+		{`trailing comma in object`, `{"a": 1,}`, "invalid JSON: expected key after comma"},
+		{`missing colon`, `{"a" 1}`, "invalid JSON"},
+		{`non-string key`, `{1: 2}`, "invalid JSON"},
+		{`unterminated array`, `[1, 2`, "unexpected end of JSON"},
+		{`array truncated after comma`, `[1,`, "unexpected end of JSON"},
+		{`array truncated after bracket`, `[`, "unexpected end of JSON"},
+		{`object truncated after value`, `{"a":1`, "unexpected end of JSON"},
+		{`object truncated after comma`, `{"a":1,`, "unexpected end of JSON"},
+		{`object truncated after key`, `{"a"`, "unexpected end of JSON"},
+		{`object truncated after brace`, `{`, "unexpected end of JSON"},
+		{`invalid byte in array`, `[1, x]`, "invalid JSON"},
+		{`mismatched bracket`, `[1}`, "invalid JSON"},
+		{`garbage after literal`, `truex`, "invalid JSON"},
+		{`garbage after number`, `0x1`, "invalid JSON"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ensurePanic(t, func() {
+				d := Decoder{Raw: Raw(test.input), Strict: true}
+				d.Value()
+				d.EnsureEOF()
+			}, test.expected)
+		})
+	}
+}
+
+func TestDecoderByteOrderMark(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		f        func(d *Decoder) any
+		expected any
+	}{
+		{`Value`, "\xEF\xBB\xBF{\"a\": 1}", (*Decoder).Value, map[string]any{"a": 1.0}},
+		{`Next`, "\xEF\xBB\xBF 1", func(d *Decoder) any { return d.Next().Raw() }, "1"},
+		{`Peek`, "\xEF\xBB\xBF[", func(d *Decoder) any { return d.Peek() }, StartArray},
+		{`PeekKind`, "\xEF\xBB\xBF\"a\"", func(d *Decoder) any { return d.PeekKind() }, String},
+		{`AtEOF`, "\xEF\xBB\xBF ", func(d *Decoder) any { return d.AtEOF() }, true},
+		{`NextComplete`, "\xEF\xBB\xBF[", func(d *Decoder) any { tok, _ := d.NextComplete(); return tok.Raw() }, "["},
+		{`partial NextComplete`, "\xEF\xBB", func(d *Decoder) any { _, ok := d.NextComplete(); return ok }, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Decoder{Raw: Raw(test.input)}
+			if actual := test.f(&d); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** %s(%q) = %v, wanted %v", test.name, test.input, actual, test.expected)
+			}
+		})
+	}
+
+	d := Decoder{Raw: Raw("\xEF\xBB")}
+	if _, ok := d.NextComplete(); ok {
+		t.Fatalf("** NextComplete() succeeded on a partial byte order mark")
+	}
+	d.Reset([]byte("\xEF\xBB\xBF[1]"))
+	if actual := d.Value(); !reflect.DeepEqual(actual, []any{1.0}) {
+		t.Errorf("** Value() after Reset = %v, wanted [1]", actual)
+	}
+
+	ensurePanic(t, func() { d := Decoder{Raw: Raw("[1,\xEF\xBB\xBF2]")}; d.Value() }, "invalid JSON: unexpected byte order mark")
+	ensurePanic(t, func() { d := Decoder{Raw: Raw("\xEF\xBB\xBF\xEF\xBB\xBF1")}; d.Value() }, "invalid JSON: unexpected byte order mark")
+
+	d = Decoder{Raw: Raw("\xEF\xBB\xBF {}")}
+	if !d.IsEmptyObject() || d.IsEmptyArray() {
+		t.Errorf("** IsEmptyObject() = false after a byte order mark")
+	}
+}
+
+func TestDecoderValidUTF8(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string // empty if valid
+	}{
+		{`valid`, `{"é€😀": "\ud83d\ude00\x"}`, ``},
+		{`replacement character`, "[\"\uFFFD\xef\xbf\xbd\"]", ``},
+		{`lead byte before quote`, "[\"a\xc3\"]", `invalid JSON: truncated UTF-8 sequence in string "\"a\xc3\""`},
+		{`lead byte before backslash`, "[\"\xe2\\\"\"]", `invalid JSON: truncated UTF-8 sequence in string "\"\xe2\\\"\""`},
+		{`three-byte sequence cut short`, "[\"\xe2\x82x\"]", `invalid JSON: truncated UTF-8 sequence in string "\"\xe2\x82x\""`},
+		{`four-byte sequence cut short`, "[\"ok\", \"\xf0\x9f\x98\"]", `invalid JSON: truncated UTF-8 sequence in string "\"\xf0\x9f\x98\""`},
+		{`in key`, "{\"\xd0\": 1}", `invalid JSON: truncated UTF-8 sequence in object key "\"\xd0\""`},
+		{`in value after key`, "{\"a\": \"\xd0\"}", `invalid JSON: truncated UTF-8 sequence in string "\"\xd0\""`},
+		{`stray continuation byte`, "[\"\x80\"]", `invalid JSON: invalid UTF-8 in string "\"\x80\""`},
+		{`overlong encoding`, "[\"\xc0\xaf\"]", `invalid JSON: invalid UTF-8 in string "\"\xc0\xaf\""`},
+		{`surrogate`, "[\"\xed\xa0\x80\"]", `invalid JSON: invalid UTF-8 in string "\"\xed\xa0\x80\""`},
+		{`invalid lead byte`, "[\"\xf8\x88\x80\x80\x80\"]", `invalid JSON: invalid UTF-8 in string "\"\xf8\x88\x80\x80\x80\""`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := Decoder{Raw: Raw(test.input), ValidUTF8: true}
+			msg, _ := capturePanic(func() {
+				d.Value()
+				d.EnsureEOF()
+			}).(string)
+			if msg != test.expected {
+				t.Errorf("** panic = %q, wanted %q", msg, test.expected)
+			}
+		})
+	}
+}
+
+func TestDecoderStrictValid(t *testing.T) {
+	const input = ` {"a": [1, 2, {"b": []}], "c": {}, "d": [[], [3]], "e": "\u00e9\n", "\u00e9\t\"\\": 4} `
+	expected := raw(input).Value()
+	if v := expected.(map[string]any)["é\t\"\\"]; v != 4.0 {
+		t.Errorf("** escaped key = %v, wanted 4", v)
+	}
+	for name, f := range map[string]func(d *Decoder) any{
+		"Value":     (*Decoder).Value,
+		"ValueIter": (*Decoder).ValueIter,
+		"Skip":      func(d *Decoder) any { d.Skip(); return expected },
+	} {
+		t.Run(name, func(t *testing.T) {
+			d := Decoder{Raw: Raw(input), Strict: true}
+			if actual := f(&d); !reflect.DeepEqual(actual, expected) {
+				t.Errorf("** %s() = %v, wanted %v", name, actual, expected)
+			}
+			d.EnsureEOF()
+		})
+	}
+}
+
+type cents int64
+
+func parseCents(raw []byte) (any, error) {
+	s := string(raw)
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > 2 {
+		return nil, errors.New("too many decimal places")
+	}
+	v, err := strconv.ParseInt(whole+(frac + "00")[:2], 10, 64)
+	return cents(v), err
+}
+
+func TestDecoderNumberParser(t *testing.T) {
+	const input = `{"price": 19.99, "items": [1, 2.5], "name": "x"}`
+	expected := map[string]any{"price": cents(1999), "items": []any{cents(100), cents(250)}, "name": "x"}
+	for name, f := range map[string]func(d *Decoder) any{
+		"Value":          (*Decoder).Value,
+		"ValueIter":      (*Decoder).ValueIter,
+		"ValueCanonical": (*Decoder).ValueCanonical,
+	} {
+		d := Decoder{Raw: Raw(input), NumberParser: parseCents}
+		if actual := f(&d); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("** %s() = %v, wanted %v", name, actual, expected)
+		}
+	}
+
+	d := Decoder{Raw: Raw(`{"b": 0.1, "a": 2}`), NumberParser: parseCents}
+	if actual, expected := d.ValueOrdered(), []KV{{"b", cents(10)}, {"a", cents(200)}}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** ValueOrdered() = %v, wanted %v", actual, expected)
+	}
+
+	d = Decoder{Raw: Raw(`3 "3" true`), NumberParser: parseCents}
+	if actual := []any{d.Scalar(), d.Scalar(), d.Scalar()}; !reflect.DeepEqual(actual, []any{cents(300), "3", true}) {
+		t.Errorf("** Scalar() returned %v", actual)
+	}
+
+	d = Decoder{Raw: Raw(`[1.005]`), NumberParser: parseCents}
+	ensurePanic(t, func() { d.Value() }, "unexpected JSON: 1.005: too many decimal places")
+}
+
+func TestDecoderNullValue(t *testing.T) {
+	type null struct{}
+	const input = `{"a": null, "b": [null, 1], "c": {"d": null}}`
+	expected := map[string]any{"a": null{}, "b": []any{null{}, 1.0}, "c": map[string]any{"d": null{}}}
+	for name, f := range map[string]func(d *Decoder) any{
+		"Value":          (*Decoder).Value,
+		"ValueIter":      (*Decoder).ValueIter,
+		"ValueCanonical": (*Decoder).ValueCanonical,
+	} {
+		d := Decoder{Raw: Raw(input), NullValue: null{}}
+		if actual := f(&d); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("** %s() = %v, wanted %v", name, actual, expected)
+		}
+	}
+
+	d := Decoder{Raw: Raw(`{"a": null}`), NullValue: null{}}
+	if actual, expected := d.ValueOrdered(), []KV{{"a", null{}}}; !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** ValueOrdered() = %v, wanted %v", actual, expected)
+	}
+
+	d = Decoder{Raw: Raw(`null`), NullValue: null{}}
+	if actual := d.Scalar(); actual != (null{}) {
+		t.Errorf("** Scalar() = %v, wanted null{}", actual)
+	}
+}
+
+func TestDecoderDuplicateKeys(t *testing.T) {
+	const input = `{"a": 1, "b": {"c": 2, "c": [3]}, "a": {"x": 4}}`
+	tests := []struct {
+		policy   DuplicateKeyPolicy
+		expected map[string]any
+	}{
+		{KeepLast, map[string]any{"a": map[string]any{"x": 4.0}, "b": map[string]any{"c": []any{3.0}}}},
+		{KeepFirst, map[string]any{"a": 1.0, "b": map[string]any{"c": 2.0}}},
+	}
+	for _, test := range tests {
+		for name, f := range map[string]func(d *Decoder) any{
+			"Value":     (*Decoder).Value,
+			"ValueIter": (*Decoder).ValueIter,
+		} {
+			d := Decoder{Raw: Raw(input), DuplicateKeys: test.policy}
+			if actual := f(&d); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** %s() with policy %d = %v, wanted %v", name, test.policy, actual, test.expected)
+			}
+			d = Decoder{Raw: Raw(input), DuplicateKeys: Error}
+			ensurePanic(t, func() { f(&d) }, `invalid JSON: duplicate key "c"`)
+		}
+	}
+
+	extra := map[string]any{"a": 1.0}
+	d := Decoder{Raw: Raw(`2 3`), DuplicateKeys: KeepFirst}
+	d.CaptureExtra(extra, Token(`"a"`))
+	d.CaptureExtra(extra, Token(`"b"`))
+	if expected := map[string]any{"a": 1.0, "b": 3.0}; !reflect.DeepEqual(extra, expected) {
+		t.Errorf("** CaptureExtra = %v, wanted %v", extra, expected)
+	}
+}
+
+func TestDecoderMaxDepth(t *testing.T) {
+	d := Decoder{Raw: Raw(`[[1], [2]] {"a": {}} {"b": []} [{"c": 1}] [[], {}]`), MaxDepth: 2}
+	for _, f := range []func() any{d.Value, d.ValueCanonical, d.ValueOrdered, d.ValueIter, func() any { return d.SkipN() }} {
+		f()
+		if d.depth != 0 {
+			t.Errorf("** depth = %d after a complete value, wanted 0", d.depth)
+		}
+	}
+	d.EnsureEOF()
+
+	for _, input := range []string{`[[[1]]]`, `{"a": {"b": {}}}`} {
+		for name, f := range map[string]func(d *Decoder){
+			"Value":          func(d *Decoder) { d.Value() },
+			"ValueCanonical": func(d *Decoder) { d.ValueCanonical() },
+			"ValueOrdered":   func(d *Decoder) { d.ValueOrdered() },
+			"ValueIter":      func(d *Decoder) { d.ValueIter() },
+			"Skip":           func(d *Decoder) { d.Skip() },
+		} {
+			d := Decoder{Raw: Raw(input), MaxDepth: 2}
+			msg := capturePanic(func() { f(&d) })
+			if msg != "invalid JSON: nesting exceeds maximum depth" {
+				t.Errorf("** %s(%s) panicked with %q, wanted depth error", name, input, msg)
+			}
+		}
+	}
+}
+
+func TestDecoderOnError(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		f        func(d *Decoder) any
+		result   any
+		expected string
+	}{
+		{`invalid token in Value`, `{"a": [1, x]}`, (*Decoder).Value, nil, "10: invalid JSON"},
+		{`invalid token in Next`, ` x`, func(d *Decoder) any { return d.Next() }, Token(nil), "0: invalid JSON"},
+		{`invalid Peek in strict mode`, "\xEF\xBB\xBF", func(d *Decoder) any { d.Strict = true; return d.Peek() }, EOF, "0: invalid JSON: unexpected byte order mark"},
+		{`invalid PeekKind`, ` x`, func(d *Decoder) any { return d.PeekKind() }, EOF, "0: invalid JSON"},
+		{`invalid PeekKind in strict mode`, "\xEF\xBB\xBF", func(d *Decoder) any { d.Strict = true; return d.PeekKind() }, EOF, "0: invalid JSON: unexpected byte order mark"},
+		{`invalid AtEOF in strict mode`, "\xEF\xBB\xBF", func(d *Decoder) any { d.Strict = true; return d.AtEOF() }, false, "0: invalid JSON: unexpected byte order mark"},
+		{`invalid NestingDepth in strict mode`, `[[1], [2 3]]`, func(d *Decoder) any { d.Strict = true; return d.NestingDepth() }, 0, "9: invalid JSON: missing comma"},
+		{`NestingDepth beyond MaxDepth`, `[[[1]]]`, func(d *Decoder) any { d.MaxDepth = 2; return d.NestingDepth() }, 0, "3: invalid JSON: nesting exceeds maximum depth"},
+		{`invalid Pointer in strict mode`, `{"a": 01, "b": 2}`, func(d *Decoder) any { d.Strict = true; tok, _ := d.Pointer("/b"); return tok }, Token(nil), "5: invalid JSON: leading zero in number 01"},
+		{`invalid PointerMany in strict mode`, `[1 2]`, func(d *Decoder) any { d.Strict = true; return d.PointerMany([]string{"/1"}) }, []Token(nil), "3: invalid JSON: missing comma"},
+		{`Get beyond MaxDepth`, `{"a": [[1]]}`, func(d *Decoder) any { d.MaxDepth = 1; return d.Get("a").Raw }, Raw(nil), "8: invalid JSON: nesting exceeds maximum depth"},
+		{`invalid At in strict mode`, `[1,, 2]`, func(d *Decoder) any { d.Strict = true; return d.At(1).Raw }, Raw(nil), "3: invalid JSON: expected value after comma"},
+		{`IsEmptyObject in strict mode`, "\xEF\xBB\xBF{}", func(d *Decoder) any { d.Strict = true; return d.IsEmptyObject() }, false, "0: invalid JSON: unexpected byte order mark"},
+		{`IsEmptyArray in strict mode`, "\xEF\xBB\xBF[]", func(d *Decoder) any { d.Strict = true; return d.IsEmptyArray() }, false, "0: invalid JSON: unexpected byte order mark"},
+		{`ValueTyped keeps NumberParser`, `[1, x]`, func(d *Decoder) any { d.ValueTyped(); return d.NumberParser == nil }, true, "4: invalid JSON"},
+		{`invalid NextComplete`, `x`, func(d *Decoder) any { tok, ok := d.NextComplete(); return ok || tok != nil }, false, "0: invalid JSON"},
+		{`wrong type`, `"a"`, func(d *Decoder) any { return d.Int() }, 0, `3: unexpected JSON: "a"`},
+		{`trailing data`, `1 2`, func(d *Decoder) any { d.Skip(); d.EnsureEOF(); return d.Raw }, Raw(nil), "2: invalid JSON"},
+		{`Tokens`, `[1 x]`, func(d *Decoder) any {
+			var kinds []Kind
+			d.Tokens()(func(kind Kind, token Token) bool {
+				kinds = append(kinds, kind)
+				return true
+			})
+			return kinds
+		}, []Kind{StartArray, Number}, "2: invalid JSON"},
+		{`MaxDepth in Value`, `{"a": [[1]]}`, func(d *Decoder) any { d.MaxDepth = 2; return d.Value() }, nil, "8: invalid JSON: nesting exceeds maximum depth"},
+		{`MaxDepth in Skip`, `[[[]]]`, func(d *Decoder) any { d.MaxDepth = 2; d.Skip(); return d.Raw }, Raw(nil), "3: invalid JSON: nesting exceeds maximum depth"},
+		{`only first error reported`, `{"a": "x", "b": 2}`, func(d *Decoder) any {
+			var n []int
+			for key := d.StartObject(); key != nil; key = d.ContinueObject() {
+				n = append(n, d.Int())
+			}
+			return n
+		}, []int{0}, `9: unexpected JSON: "x"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var errs []string
+			d := Decoder{Raw: Raw(test.input), OnError: func(offset int, msg string) {
+				errs = append(errs, fmt.Sprintf("%d: %s", offset, msg))
+			}}
+			actual := test.f(&d)
+			if !reflect.DeepEqual(actual, test.result) {
+				t.Errorf("** returned %v, wanted %v", actual, test.result)
+			}
+			if a := strings.Join(errs, "; "); a != test.expected {
+				t.Errorf("** errors: %s, wanted %s", a, test.expected)
+			}
+		})
+	}
+	failure := errors.New("parser bug")
+	d := Decoder{Raw: Raw(`[1]`), OnError: func(int, string) { t.Errorf("** OnError called for a non-JSON panic") },
+		NumberParser: func([]byte) (any, error) { panic(failure) }}
+	if e := capturePanic(func() { d.Value() }); e != failure {
+		t.Errorf("** Value() panicked with %v, wanted %v", e, failure)
+	}
+}
@@ -0,0 +1,101 @@
+package tinyjson
+
+// Filter appends src to dst without whitespace, dropping each object member
+// whose dotted path, like "user.address.city", doesn't pass keep; arrays
+// don't add to the path, so "items.id" means the id of every item. Nested
+// members of kept ones are filtered too. Strings and numbers are copied
+// verbatim. Like Raw, panics on invalid JSON, including empty input.
+//
+//	out := tinyjson.Filter(nil, data, func(path string) bool {
+//		return path != "user.password"
+//	})
+func Filter(dst, src []byte, keep func(path string) bool) []byte {
+	raw := Raw(TrimBOM(src))
+	dst = raw.filter(dst, nil, keep)
+	raw.EnsureEOF()
+	return dst
+}
+
+func (raw *Raw) filter(dst, path []byte, keep func(path string) bool) []byte {
+	t := raw.Next()
+	switch t.Kind() {
+	case EOF:
+		panic("unexpected end of JSON")
+	case StartObject:
+		dst = append(dst, '{')
+		n := 0
+		for key := raw.ContinueObject(); key != nil; key = raw.ContinueObject() {
+			p := path
+			if len(p) > 0 {
+				p = append(p, '.')
+			}
+			p = append(p, key.Str()...)
+			if !keep(string(p)) {
+				raw.Skip()
+				continue
+			}
+			if n > 0 {
+				dst = append(dst, ',')
+			}
+			n++
+			dst = key.AppendTo(dst)
+			dst = append(dst, ':')
+			dst = raw.filter(dst, p, keep)
+		}
+		return append(dst, '}')
+	case StartArray:
+		dst = append(dst, '[')
+		for n := 0; raw.ContinueArray(); n++ {
+			if n > 0 {
+				dst = append(dst, ',')
+			}
+			dst = raw.filter(dst, path, keep)
+		}
+		return append(dst, ']')
+	case String, Number, True, False, Null:
+		return t.AppendTo(dst)
+	default:
+		panic("invalid JSON")
+	}
+}
+
+// Redact returns a copy of src with the values of object members named like
+// any of keys, at any depth, replaced by "***", whatever their type. The rest
+// of src, including whitespace, is copied verbatim. Like Raw, panics on
+// invalid JSON, including empty input.
+//
+//	out := tinyjson.Redact(data, map[string]bool{"password": true, "token": true})
+func Redact(src []byte, keys map[string]bool) []byte {
+	raw := Raw(TrimBOM(src))
+	dst, last := raw.redact(nil, src, 0, keys)
+	raw.EnsureEOF()
+	return append(dst, src[last:]...)
+}
+
+// redact consumes the next value, appending src[last:] up to each redacted
+// value and "***" instead of it to dst; returns where to continue copying.
+func (raw *Raw) redact(dst, src []byte, last int, keys map[string]bool) ([]byte, int) {
+	switch raw.Peek() {
+	case EOF:
+		panic("unexpected end of JSON")
+	case StartObject:
+		for key := raw.StartObject(); key != nil; key = raw.ContinueObject() {
+			if !keys[key.Str()] {
+				dst, last = raw.redact(dst, src, last, keys)
+				continue
+			}
+			raw.Peek()
+			start := len(src) - len(*raw)
+			raw.Skip()
+			dst = append(append(dst, src[last:start]...), `"***"`...)
+			last = len(src) - len(*raw)
+		}
+	case StartArray:
+		for raw.StartArray(); raw.ContinueArray(); {
+			dst, last = raw.redact(dst, src, last, keys)
+		}
+	default:
+		raw.Skip()
+	}
+	return dst, last
+}
@@ -0,0 +1,84 @@
+package tinyjson
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilter(t *testing.T) {
+	const doc = `{
+		"user": {"name": "John", "password": "secret", "address": {"city": "Paris", "zip": "75001"}},
+		"items": [{"id": 1, "price": 9.50}, {"id": 2e3, "price": 1}, 7],
+		"token": "abc",
+		"escaped": "☺"
+	}`
+	tests := []struct {
+		name     string
+		input    string
+		keep     func(path string) bool
+		expected string
+	}{
+		{`keep all`, `{"a": [1, {"b": null}], "c": true}`, func(string) bool { return true }, `{"a":[1,{"b":null}],"c":true}`},
+		{`drop all`, doc, func(string) bool { return false }, `{}`},
+		{`drop a field`, doc, func(path string) bool { return path != "user.password" && path != "token" },
+			`{"user":{"name":"John","address":{"city":"Paris","zip":"75001"}},"items":[{"id":1,"price":9.50},{"id":2e3,"price":1},7],"escaped":"☺"}`},
+		{`drop in array elements`, doc, func(path string) bool { return path == "items" || path == "items.id" },
+			`{"items":[{"id":1},{"id":2e3},7]}`},
+		{`unescaped path`, doc, func(path string) bool { return path == "escaped" }, `{"escaped":"☺"}`},
+		{`nested prefix`, doc, func(path string) bool { return strings.HasPrefix("user.address.city", path) },
+			`{"user":{"address":{"city":"Paris"}}}`},
+		{`top-level array`, `[{"a": 1, "b": 2}, []]`, func(path string) bool { return path == "a" }, `[{"a":1},[]]`},
+		{`scalar`, ` "x" `, func(string) bool { return false }, `"x"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := string(Filter([]byte(">"), []byte(test.input), test.keep))
+			if actual != ">"+test.expected {
+				t.Errorf("** Filter(%s) = %s, wanted >%s", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFilterPanics(t *testing.T) {
+	keep := func(string) bool { return true }
+	ensurePanic(t, func() { Filter(nil, []byte(`{"a": x}`), keep) }, "invalid JSON")
+	ensurePanic(t, func() { Filter(nil, []byte(`,`), keep) }, "invalid JSON")
+	ensurePanic(t, func() { Filter(nil, []byte(`1 2`), keep) }, "invalid JSON")
+	ensurePanic(t, func() { Filter(nil, nil, keep) }, "unexpected end of JSON")
+	ensurePanic(t, func() { Filter(nil, []byte(" \n"), keep) }, "unexpected end of JSON")
+	ensurePanic(t, func() { Filter(nil, []byte(`{"a":`), keep) }, "unexpected end of JSON")
+}
+
+func TestRedact(t *testing.T) {
+	keys := map[string]bool{"password": true, "token": true, "a b": true}
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{`nothing to redact`, ` {"a": [1, {"b": null}], "c" : true} `, ` {"a": [1, {"b": null}], "c" : true} `},
+		{`top level`, `{"user": "john", "password": "secret"}`, `{"user": "john", "password": "***"}`},
+		{`nested`, "{\"u\": {\"password\" :\n 42, \"x\": 1},\n \"l\": [{\"token\": null}]}", "{\"u\": {\"password\" :\n \"***\", \"x\": 1},\n \"l\": [{\"token\": \"***\"}]}"},
+		{`subtrees`, `{"token": {"a": [1, 2]}, "password": [{"token": 1}], "ok": 1}`, `{"token": "***", "password": "***", "ok": 1}`},
+		{`escaped key`, `{"pass\u0077ord": "x", "a\u0020b": "y"}`, `{"pass\u0077ord": "***", "a\u0020b": "***"}`},
+		{`key-like strings`, `["password", {"v": "token"}]`, `["password", {"v": "token"}]`},
+		{`scalar`, `"password"`, `"password"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := string(Redact([]byte(test.input), keys))
+			if actual != test.expected {
+				t.Errorf("** Redact(%s) = %s, wanted %s", test.input, actual, test.expected)
+			}
+		})
+	}
+
+	ensurePanic(t, func() { Redact([]byte(`{"password": }`), keys) }, "invalid JSON")
+	ensurePanic(t, func() { Redact([]byte(`[1] 2`), keys) }, "invalid JSON")
+	ensurePanic(t, func() { Redact(nil, keys) }, "unexpected end of JSON")
+	ensurePanic(t, func() { Redact([]byte(" \n"), keys) }, "unexpected end of JSON")
+	ensurePanic(t, func() { Redact([]byte(`{"a":`), keys) }, "unexpected end of JSON")
+}
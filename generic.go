@@ -0,0 +1,110 @@
+package tinyjson
+
+import (
+	"runtime"
+	"sync"
+)
+
+// DecodeMap decodes a JSON object whose values all have the same type, parsing
+// each value with parse:
+//
+//	counts := tinyjson.DecodeMap(raw, (*tinyjson.Raw).Int)
+func DecodeMap[V any](raw *Raw, parse func(*Raw) V) map[string]V {
+	result := make(map[string]V)
+	for key := raw.StartObject(); key != nil; key = raw.ContinueObject() {
+		result[key.Str()] = parse(raw)
+	}
+	return result
+}
+
+// DecodeSlice decodes a JSON array whose elements all have the same type,
+// parsing each element with parse:
+//
+//	nums := tinyjson.DecodeSlice(raw, (*tinyjson.Raw).Int)
+func DecodeSlice[T any](raw *Raw, parse func(*Raw) T) []T {
+	var result []T
+	for raw.StartArray(); raw.ContinueArray(); {
+		result = append(result, parse(raw))
+	}
+	return result
+}
+
+// DecodeSliceParallel is like DecodeSlice for a document consisting of a large
+// array of independent elements, like a million records, on a multicore
+// machine. It finds the elements in a single pass, then parses them in
+// contiguous batches on GOMAXPROCS goroutines, so parse must be safe to call
+// concurrently. The result keeps the order of the array. If parse panics, one
+// of the panics is re-raised in the calling goroutine after all batches end.
+func DecodeSliceParallel[T any](data []byte, parse func(*Raw) T) []T {
+	raw := Raw(TrimBOM(data))
+	var elems []Raw
+	for raw.StartArray(); raw.ContinueArray(); {
+		raw.Peek()
+		start := raw
+		elems = append(elems, start[:raw.SkipN()])
+	}
+	raw.EnsureEOF()
+	if elems == nil {
+		return nil
+	}
+
+	result := make([]T, len(elems))
+	batch := (len(elems) + runtime.GOMAXPROCS(0) - 1) / runtime.GOMAXPROCS(0)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var failure any
+	for lo := 0; lo < len(elems); lo += batch {
+		hi := lo + batch
+		if hi > len(elems) {
+			hi = len(elems)
+		}
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			defer func() {
+				if e := recover(); e != nil {
+					once.Do(func() { failure = e })
+				}
+			}()
+			for i := lo; i < hi; i++ {
+				result[i] = parse(&elems[i])
+			}
+		}(lo, hi)
+	}
+	wg.Wait()
+	if failure != nil {
+		panic(failure)
+	}
+	return result
+}
+
+// DecodeArrayN decodes up to len(dst) elements of a JSON array into dst,
+// skipping any extra elements, and returns the number of elements written.
+// Unlike DecodeSlice, it doesn't allocate, so dst can be a stack array:
+//
+//	var coords [2]float64
+//	n := tinyjson.DecodeArrayN(raw, coords[:], (*tinyjson.Raw).Float)
+func DecodeArrayN[T any](raw *Raw, dst []T, parse func(*Raw) T) int {
+	n := 0
+	for raw.StartArray(); raw.ContinueArray(); {
+		if n < len(dst) {
+			dst[n] = parse(raw)
+			n++
+		} else {
+			raw.Skip()
+		}
+	}
+	return n
+}
+
+// DecodeOptional returns nil if the next value is null, and a pointer to
+// the value parsed by parse otherwise:
+//
+//	foo.Limit = tinyjson.DecodeOptional(raw, (*tinyjson.Raw).Int)
+func DecodeOptional[T any](raw *Raw, parse func(*Raw) T) *T {
+	if raw.Null() {
+		return nil
+	}
+	v := parse(raw)
+	return &v
+}
@@ -0,0 +1,196 @@
+package tinyjson
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+func TestDecodeMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]int
+	}{
+		{`empty`, `{}`, map[string]int{}},
+		{`values`, `{"a": 1, "b": -2, "cd": 3}`, map[string]int{"a": 1, "b": -2, "cd": 3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := DecodeMap(raw(test.input), (*Raw).Int)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** DecodeMap(%s) = %v, wanted %v", test.input, actual, test.expected)
+			}
+		})
+	}
+
+	nested := DecodeMap(raw(`{"x": {"y": "z"}}`), func(raw *Raw) map[string]string {
+		return DecodeMap(raw, (*Raw).Str)
+	})
+	if expected := map[string]map[string]string{"x": {"y": "z"}}; !reflect.DeepEqual(nested, expected) {
+		t.Errorf("** nested DecodeMap = %v, wanted %v", nested, expected)
+	}
+}
+
+func TestDecodeSlice(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []int
+	}{
+		{`empty`, `[]`, nil},
+		{`values`, `[1, 2, -3]`, []int{1, 2, -3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := DecodeSlice(raw(test.input), (*Raw).Int)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** DecodeSlice(%s) = %v, wanted %v", test.input, actual, test.expected)
+			}
+		})
+	}
+
+	nested := DecodeSlice(raw(`[["a"], [], ["b", "c"]]`), func(raw *Raw) []string {
+		return DecodeSlice(raw, (*Raw).Str)
+	})
+	if expected := [][]string{{"a"}, nil, {"b", "c"}}; !reflect.DeepEqual(nested, expected) {
+		t.Errorf("** nested DecodeSlice = %v, wanted %v", nested, expected)
+	}
+}
+
+func TestDecodeSliceParallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(3))
+
+	var buf []byte
+	buf = append(buf, '[')
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			buf = append(buf, ", "...)
+		}
+		buf = append(buf, `{"title": "t`+strconv.Itoa(i)+`", "count": `+strconv.Itoa(i)+`}`...)
+	}
+	buf = append(buf, "]\n"...)
+	parse := func(raw *Raw) *Bar {
+		bar := new(Bar)
+		bar.DecodeJSON(raw)
+		return bar
+	}
+
+	actual := DecodeSliceParallel(buf, parse)
+	expected := DecodeSlice(raw(string(buf)), parse)
+	if len(actual) != 1000 || !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** DecodeSliceParallel returned %d elements, differing from DecodeSlice", len(actual))
+	}
+
+	if actual := DecodeSliceParallel([]byte(` [1] `), (*Raw).Int); !reflect.DeepEqual(actual, []int{1}) {
+		t.Errorf("** DecodeSliceParallel([1]) = %v", actual)
+	}
+	if actual := DecodeSliceParallel([]byte(`[]`), (*Raw).Int); actual != nil {
+		t.Errorf("** DecodeSliceParallel([]) = %v, wanted nil", actual)
+	}
+	ensurePanic(t, func() { DecodeSliceParallel([]byte(`[1, 2, "x", 4]`), (*Raw).Int) }, `unexpected JSON: "x"`)
+	ensurePanic(t, func() { DecodeSliceParallel([]byte(`[1] 2`), (*Raw).Int) }, "invalid JSON")
+	ensurePanic(t, func() { DecodeSliceParallel([]byte(`{}`), (*Raw).Int) }, "unexpected JSON: {")
+}
+
+func BenchmarkDecodeSliceParallel(b *testing.B) {
+	var buf []byte
+	buf = append(buf, '[')
+	for i := 0; i < 10000; i++ {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"title": "title", "count": 42}`...)
+	}
+	buf = append(buf, ']')
+	parse := func(raw *Raw) Bar {
+		var bar Bar
+		bar.DecodeJSON(raw)
+		return bar
+	}
+	b.Run("serial", func(b *testing.B) {
+		b.SetBytes(int64(len(buf)))
+		for i := 0; i < b.N; i++ {
+			raw := Raw(buf)
+			DecodeSlice(&raw, parse)
+		}
+	})
+	b.Run("parallel", func(b *testing.B) {
+		b.SetBytes(int64(len(buf)))
+		for i := 0; i < b.N; i++ {
+			DecodeSliceParallel(buf, parse)
+		}
+	})
+}
+
+func TestDecodeArrayN(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected [3]float64
+		n        int
+	}{
+		{`empty`, `[] 42`, [3]float64{}, 0},
+		{`under`, `[1.5, 2] 42`, [3]float64{1.5, 2}, 2},
+		{`exact`, `[1, 2, 3] 42`, [3]float64{1, 2, 3}, 3},
+		{`over`, `[1, 2, 3, [4], {"x": 5}] 42`, [3]float64{1, 2, 3}, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actual [3]float64
+			r := raw(test.input)
+			n := DecodeArrayN(r, actual[:], (*Raw).Float)
+			if actual != test.expected || n != test.n {
+				t.Errorf("** DecodeArrayN(%s) = %v, %d, wanted %v, %d", test.input, actual, n, test.expected, test.n)
+			}
+			if next := r.Int(); next != 42 {
+				t.Errorf("** DecodeArrayN(%s) left the parser before %d, wanted 42", test.input, next)
+			}
+		})
+	}
+
+	data, r := Raw(`[1, 2]`), new(Raw)
+	if n := testing.AllocsPerRun(10, func() {
+		var coords [2]float64
+		*r = data
+		DecodeArrayN(r, coords[:], (*Raw).Float)
+	}); n != 0 {
+		t.Errorf("** DecodeArrayN allocated %v times", n)
+	}
+}
+
+func TestDecodeOptional(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected *int
+	}{
+		{`null`, `null`, nil},
+		{`value`, `42`, ptr(42)},
+		{`zero`, `0`, ptr(0)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := DecodeOptional(raw(test.input), (*Raw).Int)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** DecodeOptional(%s) = %v, wanted %v", test.input, actual, test.expected)
+			}
+		})
+	}
+
+	composed := DecodeSlice(raw(`[1, null, 3]`), func(raw *Raw) *int {
+		return DecodeOptional(raw, (*Raw).Int)
+	})
+	if expected := []*int{ptr(1), nil, ptr(3)}; !reflect.DeepEqual(composed, expected) {
+		t.Errorf("** DecodeSlice of DecodeOptional = %v, wanted %v", composed, expected)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
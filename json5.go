@@ -0,0 +1,246 @@
+package tinyjson
+
+import "strconv"
+
+// FromJSON5 converts a JSON5 document (https://json5.org), a format popular
+// for configuration files, into compact standard JSON for decoding with Raw,
+// Decoder or Parse. It supports these JSON5 extensions:
+//
+//   - // line and /* block */ comments;
+//   - trailing commas in objects and arrays;
+//   - single-quoted strings, \x, \0, \v and \' escapes, and unescaped
+//     control characters in strings;
+//   - object keys that are identifiers, like {name: 1}, including reserved
+//     words like true;
+//   - hexadecimal numbers like 0xFF, numbers with a leading plus sign, and
+//     numbers with a leading or trailing decimal point, like .5 and 5.;
+//   - \v, \f, no-break spaces, byte order marks and the Unicode line and
+//     paragraph separators as whitespace.
+//
+// These JSON5 features are not supported and are reported as errors:
+// multi-line strings (a backslash before a line break), Infinity and NaN,
+// which standard JSON cannot represent, and Unicode escapes in identifier
+// keys. Other Unicode whitespace isn't recognized either.
+//
+// Only the syntax is checked and converted, not the structure; decoding the
+// result reports any remaining problems, with offsets into the result rather
+// than src. Invalid syntax is reported as a *SyntaxError.
+func FromJSON5(src []byte) (dst []byte, err error) {
+	raw := Raw(src)
+	defer recoverSyntaxError(&err, src, &raw)
+	for skipJSON5Space(&raw); len(raw) > 0; skipJSON5Space(&raw) {
+		dst = appendJSON5Token(dst, &raw)
+	}
+	return dst, nil
+}
+
+// skipJSON5Space advances past any whitespace and comments.
+func skipJSON5Space(raw *Raw) {
+	data := *raw
+	for len(data) > 0 {
+		n := json5SpaceLen(data)
+		if n == 0 {
+			break
+		}
+		data = data[n:]
+		*raw = data
+	}
+}
+
+// json5SpaceLen returns the length of the whitespace character or comment
+// at the start of data, or 0 if there is none.
+func json5SpaceLen(data []byte) int {
+	switch c := data[0]; {
+	case isWhitespace(c) || c == '\v' || c == '\f':
+		return 1
+	case hasBOM(data):
+		return len(bom)
+	case len(data) >= 2 && string(data[:2]) == "\u00a0":
+		return 2
+	case len(data) >= 3 && (string(data[:3]) == "\u2028" || string(data[:3]) == "\u2029"):
+		return 3
+	case len(data) >= 2 && string(data[:2]) == "//":
+		for i := 2; i < len(data); i++ {
+			if data[i] == '\n' {
+				return i + 1
+			}
+		}
+		return len(data)
+	case len(data) >= 2 && string(data[:2]) == "/*":
+		for i := 2; i+1 < len(data); i++ {
+			if data[i] == '*' && data[i+1] == '/' {
+				return i + 2
+			}
+		}
+		panic("invalid JSON5: unterminated comment")
+	default:
+		return 0
+	}
+}
+
+// appendJSON5Token appends the JSON form of the token at the start of raw,
+// which must not be empty, and advances past it.
+func appendJSON5Token(dst []byte, raw *Raw) []byte {
+	data := *raw
+	n := 1
+	switch c := data[0]; {
+	case c == '{' || c == '[' || c == ':':
+		dst = append(dst, c)
+	case c == ',':
+		if len(dst) == 0 || dst[len(dst)-1] == '{' || dst[len(dst)-1] == '[' || dst[len(dst)-1] == ',' || dst[len(dst)-1] == ':' {
+			panic("invalid JSON5: unexpected comma") // only allowed after a value
+		}
+		dst = append(dst, c)
+	case c == '}' || c == ']':
+		if len(dst) > 0 && dst[len(dst)-1] == ',' {
+			dst = dst[:len(dst)-1] // trailing comma
+		}
+		dst = append(dst, c)
+	case c == '"' || c == '\'':
+		dst, n = appendJSON5String(dst, data)
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		dst, n = appendJSON5Number(dst, data)
+	case isJSON5IdentByte(c):
+		dst, n = appendJSON5Ident(dst, data)
+	default:
+		panic("invalid JSON5")
+	}
+	*raw = data[n:]
+	return dst
+}
+
+// appendJSON5String appends the string literal at the start of data, quoted
+// with either " or ', as a JSON string, returning its length in data.
+func appendJSON5String(dst []byte, data []byte) ([]byte, int) {
+	q := data[0]
+	dst = append(dst, '"')
+	for i := 1; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == q:
+			return append(dst, '"'), i + 1
+		case c == '"':
+			dst = append(dst, '\\', '"')
+		case c < 0x20:
+			dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+		case c == '\\' && i+1 < len(data):
+			i++
+			switch e := data[i]; e {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't', 'u':
+				dst = append(dst, '\\', e)
+			case '\'':
+				dst = append(dst, '\'')
+			case 'v':
+				dst = append(dst, `\u000b`...)
+			case '0':
+				dst = append(dst, `\u0000`...)
+			case 'x':
+				if i+2 >= len(data) || !isHexDigit(data[i+1]) || !isHexDigit(data[i+2]) {
+					panic("invalid JSON5: invalid escape sequence \\x in string")
+				}
+				dst = append(dst, '\\', 'u', '0', '0', data[i+1], data[i+2])
+				i += 2
+			case '\n', '\r':
+				panic("invalid JSON5: multi-line strings are not supported")
+			default:
+				dst = append(dst, e)
+			}
+		default:
+			dst = append(dst, c)
+		}
+	}
+	panic("invalid JSON5: unterminated string")
+}
+
+// appendJSON5Number appends the number at the start of data in JSON form,
+// returning its length in data.
+func appendJSON5Number(dst []byte, data []byte) ([]byte, int) {
+	start, i := len(dst), 0
+	switch data[0] {
+	case '-':
+		dst = append(dst, '-')
+		i++
+	case '+':
+		i++
+	}
+	rest := data[i:]
+	if isJSON5Word(rest, "Infinity") || isJSON5Word(rest, "NaN") {
+		panic("invalid JSON5: Infinity and NaN are not supported")
+	}
+
+	if len(rest) >= 2 && rest[0] == '0' && (rest[1] == 'x' || rest[1] == 'X') {
+		n := 2
+		for n < len(rest) && isHexDigit(rest[n]) {
+			n++
+		}
+		v, err := strconv.ParseUint(string(rest[2:n]), 16, 64)
+		if err != nil {
+			panic("invalid JSON5: invalid hexadecimal number " + string(data[:i+n]))
+		}
+		return strconv.AppendUint(dst, v, 10), i + n
+	}
+
+	token, _ := scanNumber(rest)
+	if !hasMantissaDigit(token) {
+		panic("invalid JSON5: invalid number " + string(data[:i+len(token)]))
+	}
+	for k, c := range token {
+		if c == '.' && k == 0 {
+			dst = append(dst, '0')
+		}
+		dst = append(dst, c)
+		if c == '.' && (k+1 == len(token) || token[k+1] < '0' || token[k+1] > '9') {
+			dst = append(dst, '0')
+		}
+	}
+	if numberProblem(Token(dst[start:])) != "" { // e.g. 1e, 01 or 1.2.3
+		panic("invalid JSON5: invalid number " + string(data[:i+len(token)]))
+	}
+	return dst, i + len(token)
+}
+
+// hasMantissaDigit reports whether the number token has a digit before its
+// exponent, if any, unlike . or +e1.
+func hasMantissaDigit(token []byte) bool {
+	for _, c := range token {
+		if c >= '0' && c <= '9' {
+			return true
+		} else if c == 'e' || c == 'E' {
+			return false
+		}
+	}
+	return false
+}
+
+// appendJSON5Ident appends the identifier at the start of data as a quoted
+// object key if a colon follows, as a literal otherwise, returning its length.
+func appendJSON5Ident(dst []byte, data []byte) ([]byte, int) {
+	n := 1
+	for n < len(data) && isJSON5IdentByte(data[n]) && json5SpaceLen(data[n:]) == 0 {
+		n++
+	}
+	word := string(data[:n])
+	after := Raw(data[n:])
+	skipJSON5Space(&after)
+	switch {
+	case len(after) > 0 && after[0] == ':':
+		return AppendEscape(dst, word), n
+	case word == "true" || word == "false" || word == "null":
+		return append(dst, word...), n
+	case word == "Infinity" || word == "NaN":
+		panic("invalid JSON5: Infinity and NaN are not supported")
+	default:
+		panic("invalid JSON5: unexpected identifier " + word)
+	}
+}
+
+// isJSON5IdentByte reports whether c can be part of an identifier. Bytes of
+// multi-byte UTF-8 characters are all accepted, approximating the Unicode
+// letters JSON5 allows.
+func isJSON5IdentByte(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c == '_' || c == '$' || c >= 0x80
+}
+
+// isJSON5Word reports whether data starts with the identifier word.
+func isJSON5Word(data []byte, word string) bool {
+	return len(data) >= len(word) && string(data[:len(word)]) == word && (len(data) == len(word) || !isJSON5IdentByte(data[len(word)]))
+}
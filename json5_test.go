@@ -0,0 +1,129 @@
+package tinyjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFromJSON5(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{`plain JSON`, `{"a": [1, -2.5e3, "x", true, false, null]}`, `{"a":[1,-2.5e3,"x",true,false,null]}`},
+		{`empty`, "  // nothing\n", ``},
+		{`line comments`, "[1, // one\n2 // two\n]", `[1,2]`},
+		{`block comments`, "/* header\n */ {/**/\"a\" /* key */: 1}", `{"a":1}`},
+		{`trailing commas`, `{"a": [1, 2, ], "b": {"c": 3,},}`, `{"a":[1,2],"b":{"c":3}}`},
+		{`trailing comma before comment`, "[1, // last\n]", `[1]`},
+		{`single quotes`, `['a"b', 'it\'s', "it's"]`, `["a\"b","it's","it's"]`},
+		{`JSON5 escapes`, `'\x41\0\v\a\/é\n'`, `"\u0041\u0000\u000ba\/é\n"`},
+		{`control characters`, "'a\tb'", `"a\u0009b"`},
+		{`identifier keys`, `{name: 1, $id_2: 2, true: 3, ключ: 4}`, `{"name":1,"$id_2":2,"true":3,"ключ":4}`},
+		{`identifier key before comment`, "{a /* x */ : 1}", `{"a":1}`},
+		{`hex numbers`, `[0xFF, -0x10, +0X1a, 0xffffffffffffffff]`, `[255,-16,26,18446744073709551615]`},
+		{`leading plus`, `[+1, +1.5e3]`, `[1,1.5e3]`},
+		{`decimal points`, `[.5, 5., -.5e1, 5.e3, +.5]`, `[0.5,5.0,-0.5e1,5.0e3,0.5]`},
+		{`extra whitespace`, "\v\f \xEF\xBB\xBF[1, 2 ]", `[1,2]`},
+		{`no-break space after identifier`, "{a\u00a0: 1}", `{"a":1}`},
+		{`line separators`, "[1,\u20282\u2029]", `[1,2]`},
+		{`comment at end`, "1 // one", `1`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := FromJSON5([]byte(test.input))
+			if err != nil {
+				t.Fatalf("** FromJSON5(%s) failed: %v", test.input, err)
+			}
+			if string(actual) != test.expected {
+				t.Errorf("** FromJSON5(%s) = %s, wanted %s", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFromJSON5Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{`unterminated comment`, `[1, /* 2 ]`, "invalid JSON5: unterminated comment at offset 4"},
+		{`unterminated string`, `['abc]`, "invalid JSON5: unterminated string at offset 1"},
+		{`backslash at end`, `'abc\`, "invalid JSON5: unterminated string at offset 0"},
+		{`multi-line string`, "['a\\\nb']", "invalid JSON5: multi-line strings are not supported at offset 1"},
+		{`invalid hex escape`, `'\x4'`, "invalid JSON5: invalid escape sequence \\x in string at offset 0"},
+		{`Infinity`, `[Infinity]`, "invalid JSON5: Infinity and NaN are not supported at offset 1"},
+		{`negative Infinity`, `-Infinity`, "invalid JSON5: Infinity and NaN are not supported at offset 0"},
+		{`NaN`, `{a: NaN}`, "invalid JSON5: Infinity and NaN are not supported at offset 4"},
+		{`signed NaN`, `+NaN`, "invalid JSON5: Infinity and NaN are not supported at offset 0"},
+		{`empty hex number`, `0x`, "invalid JSON5: invalid hexadecimal number 0x at offset 0"},
+		{`hex number out of range`, `-0x10000000000000000`, "invalid JSON5: invalid hexadecimal number -0x10000000000000000 at offset 0"},
+		{`unquoted value`, `{a: b}`, "invalid JSON5: unexpected identifier b at offset 4"},
+		{`escape in identifier`, `{\u0061: 1}`, "invalid JSON5 at offset 1"},
+		{`invalid character`, `[1, @]`, "invalid JSON5 at offset 4"},
+		{`lone decimal point`, `[.]`, "invalid JSON5: invalid number . at offset 1"},
+		{`lone plus`, `[+]`, "invalid JSON5: invalid number + at offset 1"},
+		{`lone minus`, `-`, "invalid JSON5: invalid number - at offset 0"},
+		{`exponent without mantissa`, `[.e1]`, "invalid JSON5: invalid number .e1 at offset 1"},
+		{`misplaced sign`, `1-2`, "invalid JSON5: invalid number 1-2 at offset 0"},
+		{`exponent without digits`, `1e`, "invalid JSON5: invalid number 1e at offset 0"},
+		{`signed exponent without digits`, `[1e+]`, "invalid JSON5: invalid number 1e+ at offset 1"},
+		{`multiple decimal points`, `1.2.3`, "invalid JSON5: invalid number 1.2.3 at offset 0"},
+		{`leading zero`, `01`, "invalid JSON5: invalid number 01 at offset 0"},
+		{`adjacent decimal points`, `1..2`, "invalid JSON5: invalid number 1..2 at offset 0"},
+		{`bare decimal point before exponent`, `[-.5e-]`, "invalid JSON5: invalid number -.5e- at offset 1"},
+		{`comma in empty array`, `[,]`, "invalid JSON5: unexpected comma at offset 1"},
+		{`comma in empty object`, `{,}`, "invalid JSON5: unexpected comma at offset 1"},
+		{`double trailing comma`, `[1,,]`, "invalid JSON5: unexpected comma at offset 3"},
+		{`comma instead of value`, `{a:,}`, "invalid JSON5: unexpected comma at offset 3"},
+		{`leading comma`, `,1`, "invalid JSON5: unexpected comma at offset 0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := FromJSON5([]byte(test.input))
+			if err == nil {
+				t.Fatalf("** FromJSON5(%s) succeeded, wanted %s", test.input, test.expected)
+			}
+			if _, ok := err.(*SyntaxError); !ok || err.Error() != test.expected {
+				t.Errorf("** FromJSON5(%s) failed with %T %v, wanted %s", test.input, err, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestFromJSON5Config(t *testing.T) {
+	const config = `
+	// Server configuration
+	{
+		listen: '0.0.0.0:8080',
+		timeouts: {read: 5, write: 10.,},
+		/* feature flags */
+		features: ['auth', "metrics",],
+		mask: 0x1F,
+	}
+	`
+	data, err := FromJSON5([]byte(config))
+	if err != nil {
+		t.Fatalf("** FromJSON5 failed: %v", err)
+	}
+	actual, err := Parse(data)
+	if err != nil {
+		t.Fatalf("** Parse(%s) failed: %v", data, err)
+	}
+	expected := map[string]any{
+		"listen":   "0.0.0.0:8080",
+		"timeouts": map[string]any{"read": 5.0, "write": 10.0},
+		"features": []any{"auth", "metrics"},
+		"mask":     31.0,
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** decoded %v, wanted %v", actual, expected)
+	}
+	if errs := Lint(data); errs != nil {
+		t.Errorf("** converted config isn't strict JSON: %v", errs)
+	}
+}
@@ -0,0 +1,87 @@
+package tinyjson
+
+import (
+	"bytes"
+	"unsafe"
+)
+
+// KeySet maps a fixed set of object keys to their positions in it, for
+// dispatching on keys with a switch over integers instead of comparing the
+// key against each string in turn, which adds up for objects with many
+// known keys on hot decoding paths:
+//
+//	var barKeys = tinyjson.NewKeySet("title", "count")
+//
+//	for key := raw.StartObject(); key != nil; key = raw.ContinueObject() {
+//		switch barKeys.Index(key) {
+//		case 0:
+//			bar.Title = raw.Str()
+//		case 1:
+//			bar.Count = raw.Int()
+//		default:
+//			raw.Skip()
+//		}
+//	}
+//
+// Index hashes the key into a compact table, usually taking a single string
+// comparison, and only unescapes keys that have escape sequences. A KeySet is
+// safe for concurrent use.
+type KeySet struct {
+	keys  []string
+	slots []uint16 // index+1 of the keys by hash, with linear probing, 0 if free
+}
+
+// NewKeySet builds a KeySet for the given keys, which must be distinct.
+func NewKeySet(keys ...string) *KeySet {
+	if len(keys) >= 1<<16-1 {
+		panic("tinyjson: KeySet: too many keys")
+	}
+	size := 1
+	for size < 2*len(keys) {
+		size *= 2
+	}
+	s := &KeySet{keys: keys, slots: make([]uint16, size)}
+	for i, k := range keys {
+		slot := s.find(keyHash(k), k)
+		if s.slots[slot] != 0 {
+			panic("tinyjson: KeySet: duplicate key " + k)
+		}
+		s.slots[slot] = uint16(i + 1)
+	}
+	return s
+}
+
+// Index returns the position of key in the set, or -1 if it isn't one of
+// the keys. key is an object key token as returned by ContinueObject.
+func (s *KeySet) Index(key Token) int {
+	if key.Kind() != String {
+		return -1
+	}
+	inner := key[1 : len(key)-1]
+	k := unsafe.String(unsafe.SliceData(inner), len(inner))
+	if bytes.IndexByte(inner, '\\') >= 0 {
+		k = key.Str()
+	}
+	return int(s.slots[s.find(keyHash(k), k)]) - 1
+}
+
+// find returns the slot holding k, or the free slot where k would go.
+func (s *KeySet) find(h uint32, k string) int {
+	mask := len(s.slots) - 1
+	for slot := int(h) & mask; ; slot = (slot + 1) & mask {
+		if i := s.slots[slot]; i == 0 || s.keys[i-1] == k {
+			return slot
+		}
+	}
+}
+
+// keyHash hashes the length and three of the bytes of k, which is much faster
+// than hashing all of them, and tells most sets of object keys apart.
+func keyHash(k string) uint32 {
+	h := uint32(len(k))
+	if len(k) > 0 {
+		h |= uint32(k[0])<<8 | uint32(k[len(k)/2])<<16 | uint32(k[len(k)-1])<<24
+	}
+	h *= 0x9E3779B1
+	return h ^ h>>15
+}
@@ -0,0 +1,124 @@
+package tinyjson
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestKeySet(t *testing.T) {
+	keys := []string{"title", "count", "", "é", "a\"b", "tags"}
+	s := NewKeySet(keys...)
+	for i, k := range keys {
+		if actual := s.Index(Token(AppendEscape(nil, k))); actual != i {
+			t.Errorf("** Index(%q) = %d, wanted %d", k, actual, i)
+		}
+	}
+	tests := []struct {
+		key      string
+		expected int
+	}{
+		{`"title"`, 0},
+		{`"é"`, 3},
+		{`"titl"`, -1},
+		{`"titles"`, -1},
+		{`"Title"`, -1},
+		{`"other"`, -1},
+		{`"\u0074itle"`, 0},
+		{`"\u00e9"`, 3},
+		{`"\u0078"`, -1},
+		{`title`, -1},
+		{`42`, -1},
+	}
+	for _, test := range tests {
+		if actual := s.Index(Token(test.key)); actual != test.expected {
+			t.Errorf("** Index(%s) = %d, wanted %d", test.key, actual, test.expected)
+		}
+	}
+
+	backslash := NewKeySet(`a\nb`, "a\nb2")
+	for _, test := range []struct {
+		key      string
+		expected int
+	}{
+		{`"a\nb"`, -1}, // a, newline, b
+		{`"a\\nb"`, 0},
+		{`"a\nb2"`, 1},
+	} {
+		if actual := backslash.Index(Token(test.key)); actual != test.expected {
+			t.Errorf("** Index(%s) = %d, wanted %d", test.key, actual, test.expected)
+		}
+	}
+
+	if actual := NewKeySet().Index(Token(`"x"`)); actual != -1 {
+		t.Errorf("** empty KeySet Index = %d, wanted -1", actual)
+	}
+
+	var many []string
+	for i := 0; i < 1000; i++ {
+		many = append(many, "key"+strconv.Itoa(i))
+	}
+	s = NewKeySet(many...)
+	for i, k := range many {
+		if actual := s.Index(Token(`"` + k + `"`)); actual != i {
+			t.Fatalf("** Index(%q) = %d, wanted %d", k, actual, i)
+		}
+	}
+
+	ensurePanic(t, func() { NewKeySet("a", "b", "a") }, "tinyjson: KeySet: duplicate key a")
+	ensurePanic(t, func() { NewKeySet(make([]string, 1<<16)...) }, "tinyjson: KeySet: too many keys")
+}
+
+func TestKeySetDecode(t *testing.T) {
+	barKeys := NewKeySet("title", "count")
+	raw := Raw(`{"title": "one", "extra": [1], "count": 1}`)
+	var bar Bar
+	for key := raw.StartObject(); key != nil; key = raw.ContinueObject() {
+		switch barKeys.Index(key) {
+		case 0:
+			bar.Title = raw.Str()
+		case 1:
+			bar.Count = raw.Int()
+		default:
+			raw.Skip()
+		}
+	}
+	raw.EnsureEOF()
+	if bar != (Bar{"one", 1}) {
+		t.Errorf("** decoded %+v", bar)
+	}
+}
+
+var benchmarkKeys = []string{"id", "name", "email", "created_at", "updated_at", "status", "role", "tags", "address", "phone", "country", "language", "timezone", "avatar_url", "bio", "website", "company", "title", "department", "manager_id", "hired_at", "salary", "currency", "locale", "last_login", "login_count", "is_admin", "is_active", "settings", "preferences", "notes", "version"}
+
+func BenchmarkKeySet(b *testing.B) {
+	var tokens []Token
+	for _, k := range benchmarkKeys {
+		tokens = append(tokens, Token(`"`+k+`"`))
+	}
+	b.Run("switch", func(b *testing.B) {
+		n := 0
+		for i := 0; i < b.N; i++ {
+			for _, key := range tokens {
+				switch key.Str() {
+				case "id", "name", "email", "created_at", "updated_at", "status", "role", "tags", "address", "phone", "country", "language", "timezone", "avatar_url", "bio", "website":
+					n++
+				case "company", "title", "department", "manager_id", "hired_at", "salary", "currency", "locale", "last_login", "login_count", "is_admin", "is_active", "settings", "preferences", "notes", "version":
+					n--
+				}
+			}
+		}
+	})
+	b.Run("KeySet", func(b *testing.B) {
+		s := NewKeySet(benchmarkKeys...)
+		n := 0
+		for i := 0; i < b.N; i++ {
+			for _, key := range tokens {
+				if s.Index(key) < 16 {
+					n++
+				} else {
+					n--
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,138 @@
+package tinyjson
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// LineScanner reads newline-delimited JSON (NDJSON, JSON Lines), one document
+// per line, reusing a single buffer for all lines. Blank lines are skipped.
+//
+//	s := tinyjson.NewLineScanner(f)
+//	for s.Scan() {
+//		var e Entry
+//		e.DecodeJSON(s.Raw())
+//	}
+//	if err := s.Err(); err != nil {
+//		...
+//	}
+type LineScanner struct {
+	lines *bufio.Scanner
+	raw   Raw
+}
+
+// NewLineScanner returns a LineScanner reading from r. Lines are limited to
+// bufio.MaxScanTokenSize bytes unless Buffer is called.
+func NewLineScanner(r io.Reader) *LineScanner {
+	return &LineScanner{lines: bufio.NewScanner(r)}
+}
+
+// Buffer sets the initial buffer and the maximum line length, like
+// bufio.Scanner.Buffer. It must be called before the first Scan.
+func (s *LineScanner) Buffer(buf []byte, max int) {
+	s.lines.Buffer(buf, max)
+}
+
+// Scan advances to the next non-blank line, returning false at the end of
+// input or on a read error.
+func (s *LineScanner) Scan() bool {
+	for s.lines.Scan() {
+		line := s.lines.Bytes()
+		if skipWhitespace(line) < len(line) {
+			s.raw = Raw(line)
+			return true
+		}
+	}
+	s.raw = nil
+	return false
+}
+
+// Raw returns the unparsed remainder of the current line. The bytes are only
+// valid until the next call to Scan; copy any strings that must outlive it.
+func (s *LineScanner) Raw() *Raw {
+	return &s.raw
+}
+
+// Err returns the first read error, or nil at a clean end of input.
+func (s *LineScanner) Err() error {
+	return s.lines.Err()
+}
+
+// NextSeq returns the next value of a JSON text sequence (RFC 7464), in which
+// every value is preceded by an ASCII record separator (0x1E) and usually
+// followed by a newline, or nil and false at the end of data:
+//
+//	for v, ok := raw.NextSeq(); ok; v, ok = raw.NextSeq() {
+//
+// Malformed records, like ones that lack the separator, hold anything but
+// a single value, or end with a number that may have been truncated (there
+// must be whitespace after a top-level number), are skipped up to the next
+// separator, as the RFC recommends; Decoder.Strict makes them panic instead.
+// Repeated separators are ignored.
+func (raw *Raw) NextSeq() (any, bool) {
+	return raw.nextSeq(nil)
+}
+
+const recordSeparator = 0x1E
+
+func (raw *Raw) nextSeq(d *Decoder) (any, bool) {
+	for len(*raw) > 0 {
+		data := *raw
+		end := bytes.IndexByte(data[1:], recordSeparator) + 1
+		if end == 0 {
+			end = len(data)
+		}
+		var pos int
+		if d != nil {
+			pos = d.pos
+		}
+		v, ok := Raw(data[:end]).seqRecord(d)
+		*raw = data[end:]
+		if d != nil {
+			d.pos = pos + end
+		}
+		if ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// seqRecord decodes a record of a JSON text sequence, including the leading
+// separator, returning false for empty and (unless Strict) malformed ones.
+func (rec Raw) seqRecord(d *Decoder) (v any, ok bool) {
+	if rec[0] != recordSeparator {
+		if d.strict() && skipWhitespace(rec) < len(rec) {
+			panic("invalid JSON: missing record separator")
+		}
+		return nil, false
+	}
+	rec = rec[1:]
+	if skipWhitespace(rec) == len(rec) {
+		return nil, false
+	}
+	if !d.strict() {
+		var depth int
+		if d != nil {
+			depth = d.depth
+		}
+		defer func() {
+			if e := recover(); e != nil {
+				if _, invalid := e.(string); !invalid {
+					panic(e)
+				}
+				if d != nil {
+					d.depth = depth
+				}
+				v, ok = nil, false
+			}
+		}()
+	}
+	if rec.peek(d) == Number && !isWhitespace(rec[len(rec)-1]) {
+		panic("invalid JSON: truncated number at end of record")
+	}
+	v = rec.value(d)
+	rec.ensureEOF(d)
+	return v, true
+}
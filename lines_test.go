@@ -0,0 +1,130 @@
+package tinyjson
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLineScanner(t *testing.T) {
+	input := "{\"a\":1}\n\n  \r\n[2, 3]\r\n\"x\"\n4"
+	s := NewLineScanner(strings.NewReader(input))
+	var actual []any
+	for s.Scan() {
+		actual = append(actual, s.Raw().Value())
+		s.Raw().EnsureEOF()
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("** Err() = %v, wanted nil", err)
+	}
+	expected := []any{map[string]any{"a": 1.0}, []any{2.0, 3.0}, "x", 4.0}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** scanned %v, wanted %v", actual, expected)
+	}
+	if *s.Raw() != nil {
+		t.Errorf("** Raw() = %q after the end, wanted nil", *s.Raw())
+	}
+}
+
+func TestLineScannerErrors(t *testing.T) {
+	failure := errors.New("disk on fire")
+	s := NewLineScanner(io.MultiReader(strings.NewReader("1\n"), errReader{failure}))
+	if !s.Scan() || s.Raw().Int() != 1 {
+		t.Fatalf("** first line not scanned")
+	}
+	if s.Scan() {
+		t.Errorf("** Scan() = true after a read error")
+	}
+	if err := s.Err(); err != failure {
+		t.Errorf("** Err() = %v, wanted %v", err, failure)
+	}
+
+	s = NewLineScanner(strings.NewReader(`"` + strings.Repeat("x", 100) + `"`))
+	s.Buffer(make([]byte, 16), 64)
+	if s.Scan() {
+		t.Errorf("** Scan() = true for a line over the limit")
+	}
+	if err := s.Err(); err != bufio.ErrTooLong {
+		t.Errorf("** Err() = %v, wanted %v", err, bufio.ErrTooLong)
+	}
+}
+
+func TestLineScannerReusesBuffer(t *testing.T) {
+	input := strings.Repeat(`{"id": 123, "msg": "hello"}`+"\n", 10000)
+	allocs := testing.AllocsPerRun(1, func() {
+		s := NewLineScanner(strings.NewReader(input))
+		for s.Scan() {
+			for key := s.Raw().StartObject(); key != nil; key = s.Raw().ContinueObject() {
+				s.Raw().Skip()
+			}
+		}
+	})
+	if allocs > 10 {
+		t.Errorf("** scanning 10000 lines made %v allocations, wanted a constant few", allocs)
+	}
+}
+
+func TestNextSeq(t *testing.T) {
+	const input = "garbage\n\x1e{\"a\":1}\n\x1e\x1e[2, 3]\n\x1e\"x\"\n" + // no separator, repeated separators
+		"\x1e[4\n\x1e5 6\n\x1e7\x1e 8 \x1e\n\x1enull\n\n" // unterminated, two values, truncated, blank
+	expected := []any{map[string]any{"a": 1.0}, []any{2.0, 3.0}, "x", 8.0, nil}
+
+	var actual []any
+	r := raw(input)
+	for v, ok := r.NextSeq(); ok; v, ok = r.NextSeq() {
+		actual = append(actual, v)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** NextSeq() = %v, wanted %v", actual, expected)
+	}
+
+	actual = nil
+	d := Decoder{Raw: Raw(input), MaxDepth: 5}
+	for v, ok := d.NextSeq(); ok; v, ok = d.NextSeq() {
+		actual = append(actual, v)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** Decoder.NextSeq() = %v, wanted %v", actual, expected)
+	}
+	if d.pos != len(input) || d.depth != 0 {
+		t.Errorf("** Decoder.NextSeq() ended at offset %d, depth %d, wanted %d, 0", d.pos, d.depth, len(input))
+	}
+
+	if v, ok := raw("").NextSeq(); v != nil || ok {
+		t.Errorf("** NextSeq() of no data = %v, %v, wanted nil, false", v, ok)
+	}
+}
+
+func TestNextSeqStrict(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1\n", "invalid JSON: missing record separator"},
+		{"\x1e[4\n\x1e5\n", "unexpected end of JSON"},
+		{"\x1e5 6\n", "invalid JSON"},
+		{"\x1e7", "invalid JSON: truncated number at end of record"},
+	}
+	for _, test := range tests {
+		d := Decoder{Raw: Raw(test.input), Strict: true}
+		ensurePanic(t, func() { d.NextSeq() }, test.expected)
+	}
+
+	d := Decoder{Raw: Raw("\n\x1e1\n"), Strict: true}
+	if v, ok := d.NextSeq(); v != 1.0 || !ok {
+		t.Errorf("** NextSeq() after whitespace = %v, %v, wanted 1, true", v, ok)
+	}
+
+	failure := errors.New("not a number")
+	d = Decoder{Raw: Raw("\x1e1\n"), NumberParser: func([]byte) (any, error) { panic(failure) }}
+	if e := capturePanic(func() { d.NextSeq() }); e != failure {
+		t.Errorf("** NextSeq() panicked with %v, wanted %v", e, failure)
+	}
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
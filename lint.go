@@ -0,0 +1,221 @@
+package tinyjson
+
+import "strings"
+
+// Lint validates data as a single strict JSON document (see Decoder.Strict)
+// and returns all problems found, in order, or nil if data is valid. Unlike
+// Parse, it doesn't stop at the first problem: it skips over invalid tokens
+// and assumes missing punctuation, to report further problems with the rest
+// of the document. Some problems may cause follow-up ones, though.
+func Lint(data []byte) []SyntaxError {
+	l := linter{data: data, state: lintValue}
+	for l.step() {
+	}
+	return l.errs
+}
+
+type lintState int
+
+const (
+	lintValue      lintState = iota // expecting a value
+	lintValueOrEnd                  // after [, expecting a value or ]
+	lintKey                         // after a comma in an object
+	lintKeyOrEnd                    // after {, expecting a key or }
+	lintColon                       // after a key
+	lintAfterValue                  // expecting a comma or a closing bracket
+)
+
+type linter struct {
+	data  []byte
+	pos   int
+	state lintState
+	stack []Kind // StartObject or StartArray for each open container
+	errs  []SyntaxError
+}
+
+// step processes the next token, returning false when done.
+func (l *linter) step() bool {
+	kind, start, bad := l.next()
+	if kind == EOF {
+		if len(l.stack) > 0 || l.state != lintAfterValue {
+			l.fail(start, "unexpected end of JSON")
+		}
+		return false
+	}
+	if l.state == lintAfterValue && len(l.stack) == 0 {
+		if !bad {
+			l.fail(start, "invalid JSON: trailing data")
+		}
+		return false
+	}
+	if bad {
+		// already reported; assume it was whatever was expected
+		switch l.state {
+		case lintKey, lintKeyOrEnd:
+			l.state = lintColon
+		default:
+			l.state = lintAfterValue
+		}
+		return true
+	}
+	for l.handle(kind, start) {
+		// reprocess the token in the state assumed after an error
+	}
+	return true
+}
+
+// handle processes a valid token, returning true if it must be processed
+// again because the state has been changed to recover from an error.
+func (l *linter) handle(kind Kind, start int) bool {
+	switch l.state {
+	case lintValue, lintValueOrEnd:
+		switch kind {
+		case StartObject, StartArray:
+			l.stack = append(l.stack, kind)
+			l.state = lintKeyOrEnd
+			if kind == StartArray {
+				l.state = lintValueOrEnd
+			}
+		case String, Number, True, False, Null:
+			l.state = lintAfterValue
+		case EndArray:
+			if l.state == lintValueOrEnd {
+				l.close(kind, start)
+				return false
+			}
+			l.fail(start, "invalid JSON: expected value")
+			l.state = lintAfterValue
+			return true
+		case EndObject, Comma:
+			l.fail(start, "invalid JSON: expected value")
+			l.state = lintAfterValue
+			return true
+		default:
+			l.fail(start, "invalid JSON: expected value")
+			l.state = lintAfterValue
+		}
+	case lintKey, lintKeyOrEnd:
+		switch kind {
+		case String:
+			l.state = lintColon
+		case EndObject, EndArray:
+			if l.state == lintKey {
+				l.fail(start, "invalid JSON: expected key")
+			}
+			l.close(kind, start)
+		case Comma:
+			l.fail(start, "invalid JSON: expected key")
+		default:
+			l.fail(start, "invalid JSON: expected key")
+			l.state = lintColon
+		}
+	case lintColon:
+		if kind == Colon {
+			l.state = lintValue
+			return false
+		}
+		l.fail(start, "invalid JSON: expected colon")
+		l.state = lintValue
+		return true
+	case lintAfterValue:
+		if len(l.stack) == 0 {
+			return false // a stray token at the top level, already reported
+		}
+		switch kind {
+		case Comma:
+			l.state = lintValue
+			if l.stack[len(l.stack)-1] == StartObject {
+				l.state = lintKey
+			}
+		case EndObject, EndArray:
+			l.close(kind, start)
+		default:
+			l.fail(start, "invalid JSON: expected comma")
+			l.state = lintValue
+			if l.stack[len(l.stack)-1] == StartObject {
+				l.state = lintKey
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// close pops the innermost container, which must exist, with the given bracket.
+func (l *linter) close(kind Kind, start int) {
+	top := l.stack[len(l.stack)-1]
+	if (top == StartObject) != (kind == EndObject) {
+		l.fail(start, "invalid JSON: mismatched "+string(kind)+" closing "+string(top))
+	}
+	l.stack = l.stack[:len(l.stack)-1]
+	l.state = lintAfterValue
+}
+
+// next scans the next token, returning its kind and offset. Invalid tokens are
+// reported, skipped and returned as bad with String kind if they look like
+// a string, Number otherwise.
+func (l *linter) next() (kind Kind, start int, bad bool) {
+	data := l.data
+	i := l.pos
+	for i < len(data) {
+		if isWhitespace(data[i]) {
+			i++
+		} else if hasBOM(data[i:]) {
+			l.fail(i, "invalid JSON: unexpected byte order mark")
+			i += len(bom)
+		} else {
+			break
+		}
+	}
+	if i == len(data) {
+		l.pos = i
+		return EOF, i, false
+	}
+
+	token, remainder, msg := lintScan(data[i:])
+	l.pos = len(data) - len(remainder)
+	if msg != "" {
+		l.fail(i, msg)
+		if data[i] == '"' {
+			return String, i, true
+		}
+		return Number, i, true
+	}
+	return token.Kind(), i, false
+}
+
+// lintScan is like a strict nextToken, but returns the panic message for an
+// invalid token instead, skipping over what looks like the rest of it.
+func lintScan(data []byte) (token Token, remainder []byte, msg string) {
+	defer func() {
+		if e := recover(); e != nil {
+			msg, remainder = panicMessage(e), lintResync(data)
+		}
+	}()
+	token, remainder = nextToken(data, true, "string")
+	return
+}
+
+func lintResync(data []byte) []byte {
+	if data[0] == '"' {
+		for i := 1; i < len(data); i++ {
+			switch data[i] {
+			case '\\':
+				i++
+			case '"':
+				return data[i+1:]
+			}
+		}
+		return nil
+	}
+	for i := 1; i < len(data); i++ {
+		if isWhitespace(data[i]) || strings.IndexByte(`,:[]{}"`, data[i]) >= 0 {
+			return data[i:]
+		}
+	}
+	return nil
+}
+
+func (l *linter) fail(offset int, msg string) {
+	l.errs = append(l.errs, SyntaxError{Msg: msg, Offset: offset})
+}
@@ -0,0 +1,66 @@
+package tinyjson
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string // errors formatted like "offset: msg; ..."
+	}{
+		{`valid`, `{"a": [1, -2.5e3, "x\n", true, false, null, {}, []]}`, ``},
+		{`valid scalar`, ` 1 `, ``},
+		{`empty`, ``, `0: unexpected end of JSON`},
+		{`unclosed`, `{"a": [1`, `8: unexpected end of JSON`},
+		{`missing value at end`, `{"a":`, `5: unexpected end of JSON`},
+		{`trailing data`, `1 2`, `2: invalid JSON: trailing data`},
+		{`trailing invalid token`, `1 x`, `2: invalid JSON`},
+		{`stray closing bracket`, `}`, `0: invalid JSON: expected value`},
+		{`stray colon`, `:`, `0: invalid JSON: expected value`},
+		{`byte order mark`, "\xEF\xBB\xBF[1, \xEF\xBB\xBF2]", `0: invalid JSON: unexpected byte order mark; 7: invalid JSON: unexpected byte order mark`},
+		{`every problem`, `{"a": 01, "b": tru, "c": "\x", "d": [1 2], "e" 5, "f": 1.}`,
+			`6: invalid JSON: leading zero in number 01; 15: invalid JSON; 25: invalid JSON: invalid escape sequence \x in string "\x"; 39: invalid JSON: expected comma; 47: invalid JSON: expected colon; 55: invalid JSON: decimal point without following digits in number 1.`},
+		{`trailing commas`, `[1, {"a": 1,}, ]`, `12: invalid JSON: expected key; 15: invalid JSON: expected value`},
+		{`missing values`, `[, 1] {"a": }`, `1: invalid JSON: expected value; 6: invalid JSON: trailing data`},
+		{`missing object value`, `{"a": , "b": }`, `6: invalid JSON: expected value; 13: invalid JSON: expected value`},
+		{`mismatched brackets`, `[{"a": 1], 2}`, `8: invalid JSON: mismatched ] closing {; 12: invalid JSON: mismatched } closing [`},
+		{`mismatched after start`, `[}`, `1: invalid JSON: expected value; 1: invalid JSON: mismatched } closing [`},
+		{`key problems`, `{, 1: 2, "a": 3 ]`, `1: invalid JSON: expected key; 3: invalid JSON: expected key; 16: invalid JSON: mismatched ] closing {`},
+		{`invalid tokens`, `{x: 1, "y": [z]}`, `1: invalid JSON; 13: invalid JSON`},
+		{`colon as value`, `[:]`, `1: invalid JSON: expected value`},
+		{`unterminated string`, `["abc, 1]`, `1: invalid JSON: unterminated string; 9: unexpected end of JSON`},
+		{`missing comma in array`, `[1, 2 3, 4]`, `6: invalid JSON: expected comma`},
+		{`missing comma in object`, `{"a": 1 "b": 2}`, `8: invalid JSON: expected comma`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var msgs []string
+			for _, e := range Lint([]byte(test.input)) {
+				msgs = append(msgs, fmt.Sprintf("%d: %s", e.Offset, e.Msg))
+			}
+			if actual := strings.Join(msgs, "; "); actual != test.expected {
+				t.Errorf("** Lint(%s) = %s, wanted %s", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func FuzzLint(f *testing.F) {
+	f.Add([]byte(`{"a": [1, 2, {"b": null}], "c": "d"}`))
+	f.Add([]byte(`[}`))
+	f.Add([]byte(`{,:]`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Lint is stricter than Parse, so anything it accepts must parse,
+		// except for numbers out of float64 range, which are valid JSON
+		if errs := Lint(data); errs == nil {
+			if _, err := Parse(data); err != nil && !strings.HasPrefix(err.(*SyntaxError).Msg, "unexpected JSON") {
+				t.Errorf("** Lint(%q) found no problems, but Parse failed with %v", data, err)
+			}
+		}
+	})
+}
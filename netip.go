@@ -0,0 +1,37 @@
+//go:build !tinyjson_nonetip
+
+// Accessors that depend on the net/netip package. Build with -tags
+// tinyjson_nonetip to leave them (and the net/netip package) out of minimal
+// binaries.
+
+package tinyjson
+
+import "net/netip"
+
+// Addr returns a netip.Addr parsed from a JSON string like "10.0.0.1" or
+// "fe80::1%eth0" via netip.ParseAddr, panics if impossible.
+func (t Token) Addr() netip.Addr {
+	if t.Kind() == String {
+		if v, err := netip.ParseAddr(unquoteString(t)); err == nil {
+			return v
+		}
+	}
+	panic(unexpected(t))
+}
+
+// Prefix returns a netip.Prefix parsed from a JSON string in CIDR notation
+// like "10.0.0.0/8" via netip.ParsePrefix, panics if impossible.
+func (t Token) Prefix() netip.Prefix {
+	if t.Kind() == String {
+		if v, err := netip.ParsePrefix(unquoteString(t)); err == nil {
+			return v
+		}
+	}
+	panic(unexpected(t))
+}
+
+func (raw *Raw) Addr() netip.Addr     { return raw.Next().Addr() }   // Addr returns .Next().Addr()
+func (raw *Raw) Prefix() netip.Prefix { return raw.Next().Prefix() } // Prefix returns .Next().Prefix()
+
+func (d *Decoder) Addr() netip.Addr     { defer d.catch(); return d.Raw.next(d).Addr() }   // Addr returns .Next().Addr()
+func (d *Decoder) Prefix() netip.Prefix { defer d.catch(); return d.Raw.next(d).Prefix() } // Prefix returns .Next().Prefix()
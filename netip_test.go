@@ -0,0 +1,76 @@
+//go:build !tinyjson_nonetip
+
+package tinyjson
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestAddr(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected netip.Addr
+	}{
+		{`"10.0.0.1"`, netip.AddrFrom4([4]byte{10, 0, 0, 1})},
+		{`"::1"`, netip.IPv6Loopback()},
+		{`"fe80::1%eth0"`, netip.MustParseAddr("fe80::1%eth0")},
+		{`"10.0.0.2"`, netip.AddrFrom4([4]byte{10, 0, 0, 2})},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if actual := raw(test.input).Addr(); actual != test.expected {
+				t.Errorf("** Raw.Addr(%s) = %v, wanted %v", test.input, actual, test.expected)
+			}
+			d := Decoder{Raw: Raw(test.input)}
+			if actual := d.Addr(); actual != test.expected {
+				t.Errorf("** Decoder.Addr(%s) = %v, wanted %v", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected netip.Prefix
+	}{
+		{`"10.0.0.0/8"`, netip.PrefixFrom(netip.AddrFrom4([4]byte{10, 0, 0, 0}), 8)},
+		{`"192.168.1.5/24"`, netip.PrefixFrom(netip.AddrFrom4([4]byte{192, 168, 1, 5}), 24)},
+		{`"2001:db8::/32"`, netip.MustParsePrefix("2001:db8::/32")},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			if actual := raw(test.input).Prefix(); actual != test.expected {
+				t.Errorf("** Raw.Prefix(%s) = %v, wanted %v", test.input, actual, test.expected)
+			}
+			d := Decoder{Raw: Raw(test.input)}
+			if actual := d.Prefix(); actual != test.expected {
+				t.Errorf("** Decoder.Prefix(%s) = %v, wanted %v", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestNetipPanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		f        func()
+		expected string
+	}{
+		{`number cannot Addr`, func() { raw(`10`).Addr() }, "unexpected JSON: 10"},
+		{`invalid Addr`, func() { raw(`"10.0.0.256"`).Addr() }, `unexpected JSON: "10.0.0.256"`},
+		{`Prefix cannot Addr`, func() { raw(`"10.0.0.0/8"`).Addr() }, `unexpected JSON: "10.0.0.0/8"`},
+		{`Addr cannot Prefix`, func() { raw(`"10.0.0.1"`).Prefix() }, `unexpected JSON: "10.0.0.1"`},
+		{`invalid Prefix`, func() { raw(`"10.0.0.0/33"`).Prefix() }, `unexpected JSON: "10.0.0.0/33"`},
+		{`null cannot Prefix`, func() { raw(`null`).Prefix() }, "unexpected JSON: null"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ensurePanic(t, test.f, test.expected)
+		})
+	}
+}
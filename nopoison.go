@@ -0,0 +1,16 @@
+//go:build !tinyjson_poison
+
+package tinyjson
+
+const poisonEnabled = false
+
+func aliased(s string) string {
+	return s
+}
+
+// PoisonAliases overwrites every string returned so far that would point
+// into the input, when built with -tags tinyjson_poison, to catch code that
+// keeps such strings after reusing the input buffer. Such a build tracks them
+// until the next call, so call it regularly, e.g. once per message. A no-op
+// otherwise.
+func PoisonAliases() {}
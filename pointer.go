@@ -0,0 +1,237 @@
+package tinyjson
+
+import "strings"
+
+// Pointer finds the value at the given JSON Pointer (RFC 6901), like
+// "/bars/0/title", skipping over everything else, and returns it as a single
+// token spanning the whole value, or false if there is no such value or the
+// pointer is malformed. The empty pointer refers to the whole document.
+// For objects and arrays, decode the result with Raw(token).
+func (raw Raw) Pointer(ptr string) (Token, bool) {
+	return raw.pointer(nil, ptr)
+}
+
+func (raw *Raw) pointer(d *Decoder, ptr string) (Token, bool) {
+	path, ok := parsePointer(ptr)
+	if !ok {
+		return nil, false
+	}
+	for _, ref := range path {
+		if !raw.enterMember(d, ref) {
+			return nil, false
+		}
+	}
+	return raw.valueToken(d)
+}
+
+// Get returns the value of member key of the object at the start of raw, or
+// a missing Lookup if raw doesn't start with an object or the object has no
+// such member. Like Pointer, it doesn't consume raw, and calls chain for
+// ad-hoc extraction:
+//
+//	name := raw.Get("user").Get("name").Str()
+func (raw Raw) Get(key string) Lookup {
+	return raw.get(nil, key)
+}
+
+func (raw *Raw) get(d *Decoder, key string) Lookup {
+	if raw.peek(d) == StartObject {
+		for k := raw.startObject(d); k != nil; k = raw.continueObject(d) {
+			if k.KeyIs(key) {
+				return raw.lookup(d)
+			}
+			raw.skip(d)
+		}
+	}
+	return Lookup{}
+}
+
+// At is like Get for element i of an array.
+func (raw Raw) At(i int) Lookup {
+	return raw.at(nil, i)
+}
+
+func (raw *Raw) at(d *Decoder, i int) Lookup {
+	if raw.peek(d) == StartArray && raw.arrayElement(d, i) {
+		return raw.lookup(d)
+	}
+	return Lookup{}
+}
+
+func (raw *Raw) lookup(d *Decoder) Lookup {
+	t, _ := raw.valueToken(d)
+	return Lookup{Raw(t)}
+}
+
+// Lookup is a value found by Raw.Get or Raw.At, or a missing one, which reads
+// as zero values, so that a chain of lookups needs no checks in between:
+//
+//	id := raw.Get("user").Get("id").Int() // 0 without a user or its id
+//
+// Accessors still panic if the value is present, but has another type.
+type Lookup struct {
+	Raw Raw // the whole value, or nil if missing
+}
+
+func (l Lookup) Missing() bool         { return l.Raw == nil }     // Missing reports whether no value was found
+func (l Lookup) Get(key string) Lookup { return l.Raw.Get(key) }   // Get is like Raw.Get
+func (l Lookup) At(i int) Lookup       { return l.Raw.At(i) }      // At is like Raw.At
+func (l Lookup) Kind() Kind            { return l.Raw.PeekKind() } // Kind returns the kind of the value, EOF if missing
+
+func (l Lookup) Str() string    { return lookupAs(l, (*Raw).Str) }    // Str is like Raw.Str, "" if missing
+func (l Lookup) Int() int       { return lookupAs(l, (*Raw).Int) }    // Int is like Raw.Int, 0 if missing
+func (l Lookup) Int64() int64   { return lookupAs(l, (*Raw).Int64) }  // Int64 is like Raw.Int64, 0 if missing
+func (l Lookup) Uint64() uint64 { return lookupAs(l, (*Raw).Uint64) } // Uint64 is like Raw.Uint64, 0 if missing
+func (l Lookup) Float() float64 { return lookupAs(l, (*Raw).Float) }  // Float is like Raw.Float, 0 if missing
+func (l Lookup) Bool() bool     { return lookupAs(l, (*Raw).Bool) }   // Bool is like Raw.Bool, false if missing
+func (l Lookup) Null() bool     { return lookupAs(l, (*Raw).Null) }   // Null reports whether the value is null, false if missing
+func (l Lookup) Value() any     { return lookupAs(l, (*Raw).Value) }  // Value is like Raw.Value, nil if missing
+
+func lookupAs[T any](l Lookup, f func(*Raw) T) (v T) {
+	if l.Raw != nil {
+		v = f(&l.Raw)
+	}
+	return v
+}
+
+// PointerMany is like Pointer for each of ptrs, but finds all values in a
+// single pass over the document. The result has a token for each pointer,
+// nil if not found.
+func (raw Raw) PointerMany(ptrs []string) []Token {
+	return raw.pointerMany(nil, ptrs)
+}
+
+func (raw *Raw) pointerMany(d *Decoder, ptrs []string) []Token {
+	result := make([]Token, len(ptrs))
+	var targets []pointerTarget
+	for i, ptr := range ptrs {
+		if path, ok := parsePointer(ptr); ok {
+			targets = append(targets, pointerTarget{path, i})
+		}
+	}
+	if len(targets) > 0 && raw.peek(d) != EOF {
+		raw.collect(d, targets, 0, result)
+	}
+	return result
+}
+
+type pointerTarget struct {
+	path  []string
+	index int // in the PointerMany result
+}
+
+// collect consumes the next value, storing it into result for each target
+// pointing at it, and descending into the members and elements that other
+// targets point into. All targets share the first depth path elements.
+func (raw *Raw) collect(d *Decoder, targets []pointerTarget, depth int, result []Token) {
+	kind := raw.peek(d)
+	start := *raw
+	var inner []pointerTarget
+	for _, t := range targets {
+		if len(t.path) > depth {
+			inner = append(inner, t)
+		}
+	}
+
+	switch {
+	case len(inner) > 0 && kind == StartObject:
+		for key := raw.startObject(d); key != nil; key = raw.continueObject(d) {
+			var matched []pointerTarget
+			for k, t := range inner {
+				if t.index >= 0 && key.KeyIs(t.path[depth]) {
+					matched = append(matched, t)
+					inner[k].index = -1 // like Pointer, only look in the first of duplicate keys
+				}
+			}
+			raw.collectOrSkip(d, matched, depth+1, result)
+		}
+	case len(inner) > 0 && kind == StartArray:
+		i := 0
+		for raw.startArray(d); raw.continueArray(d); i++ {
+			var matched []pointerTarget
+			for _, t := range inner {
+				if j, ok := arrayIndex(t.path[depth]); ok && j == i {
+					matched = append(matched, t)
+				}
+			}
+			raw.collectOrSkip(d, matched, depth+1, result)
+		}
+	default:
+		raw.skip(d)
+	}
+
+	token := Token(start[:len(start)-len(*raw)])
+	for _, t := range targets {
+		if len(t.path) == depth {
+			result[t.index] = token
+		}
+	}
+}
+
+func (raw *Raw) collectOrSkip(d *Decoder, targets []pointerTarget, depth int, result []Token) {
+	if targets == nil {
+		raw.skip(d)
+	} else {
+		raw.collect(d, targets, depth, result)
+	}
+}
+
+// enterMember advances into the member or element ref of the next value,
+// returning false, with the value partially consumed, if there is none.
+func (raw *Raw) enterMember(d *Decoder, ref string) bool {
+	switch raw.peek(d) {
+	case StartObject:
+		for key := raw.startObject(d); key != nil; key = raw.continueObject(d) {
+			if key.KeyIs(ref) {
+				return true
+			}
+			raw.skip(d)
+		}
+	case StartArray:
+		i, ok := arrayIndex(ref)
+		return ok && raw.arrayElement(d, i)
+	}
+	return false
+}
+
+// valueToken consumes the next value and returns its bytes, or false at EOF.
+func (raw *Raw) valueToken(d *Decoder) (Token, bool) {
+	if raw.peek(d) == EOF {
+		return nil, false
+	}
+	start := *raw
+	return Token(start[:raw.skipN(d)]), true
+}
+
+// parsePointer splits a JSON Pointer into unescaped reference tokens.
+func parsePointer(ptr string) ([]string, bool) {
+	if ptr == "" {
+		return nil, true
+	}
+	if ptr[0] != '/' {
+		return nil, false
+	}
+	path := strings.Split(ptr[1:], "/")
+	for i, ref := range path {
+		if strings.IndexByte(ref, '~') >= 0 {
+			path[i] = strings.ReplaceAll(strings.ReplaceAll(ref, "~1", "/"), "~0", "~")
+		}
+	}
+	return path, true
+}
+
+// arrayIndex parses an RFC 6901 array index: decimal digits without leading
+// zeros. The "-" index, referring past the last element, never matches.
+func arrayIndex(ref string) (int, bool) {
+	if ref == "" || len(ref) > 9 || (ref[0] == '0' && len(ref) > 1) {
+		return 0, false
+	}
+	i := 0
+	for _, c := range []byte(ref) {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		i = i*10 + int(c-'0')
+	}
+	return i, true
+}
@@ -0,0 +1,154 @@
+package tinyjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPointer(t *testing.T) {
+	const doc = `{
+		"name": "test",
+		"bars": [{"title": "one", "count": 1}, {"title": "two", "count": 2}],
+		"a/b": 1, "m~n": 2, "": 3, "c%d": 4, " ": 5, "e\"f": 6,
+		"nested": {"x": {"y": [10, [20, 30]]}}
+	}`
+	tests := []struct {
+		ptr      string
+		expected string // empty if not found
+	}{
+		{"", doc},
+		{"/name", `"test"`},
+		{"/bars/0/title", `"one"`},
+		{"/bars/1", `{"title": "two", "count": 2}`},
+		{"/bars/1/count", `2`},
+		{"/a~1b", `1`},
+		{"/m~0n", `2`},
+		{"/", `3`},
+		{"/c%d", `4`},
+		{"/ ", `5`},
+		{"/e\"f", `6`},
+		{"/nested/x/y/1/0", `20`},
+		{"/nested/x/y/1", `[20, 30]`},
+		{"/missing", ``},
+		{"/bars/2", ``},
+		{"/bars/-", ``},
+		{"/bars/01", ``},
+		{"/bars/x", ``},
+		{"/bars/", ``},
+		{"/bars/9999999999", ``},
+		{"/name/x", ``},
+		{"/bars/0/title/x", ``},
+		{"name", ``},
+	}
+
+	var ptrs []string
+	for _, test := range tests {
+		ptrs = append(ptrs, test.ptr)
+	}
+	for i, token := range Raw(doc).PointerMany(ptrs) {
+		if string(token) != tests[i].expected || (token == nil) != (tests[i].expected == "") {
+			t.Errorf("** PointerMany()[%d] (%q) = %s, wanted %s", i, tests[i].ptr, token, tests[i].expected)
+		}
+	}
+
+	for _, test := range tests {
+		t.Run(test.ptr, func(t *testing.T) {
+			raw := Raw(doc)
+			actual, ok := raw.Pointer(test.ptr)
+			if ok != (test.expected != "") || string(actual) != test.expected {
+				t.Errorf("** Pointer(%q) = %s, %v, wanted %s", test.ptr, actual, ok, test.expected)
+			}
+			if string(raw) != doc {
+				t.Errorf("** Pointer(%q) consumed input", test.ptr)
+			}
+		})
+	}
+
+	if tok, ok := Raw(`  `).Pointer(""); ok {
+		t.Errorf("** Pointer on empty input = %s, wanted not found", tok)
+	}
+	if tok, ok := Raw(`[0, 1, 2]`).Pointer("/2"); !ok || tok.Int() != 2 {
+		t.Errorf("** Pointer(/2) = %s, %v, wanted 2", tok, ok)
+	}
+}
+
+func TestGetAt(t *testing.T) {
+	raw := Raw(`{"user": {"name": "Ann", "ids": [10, [20, 30]], "ok": true, "f": 1.5}, "user": {"name": "dup"}, "n": null}`)
+	if actual := raw.Get("user").Get("name").Str(); actual != "Ann" {
+		t.Errorf("** user.name = %q, wanted Ann", actual)
+	}
+	if actual := raw.Get("user").Get("ids").At(1).At(0).Int(); actual != 20 {
+		t.Errorf("** user.ids[1][0] = %d, wanted 20", actual)
+	}
+	if actual := raw.Get("user").Get("ids"); string(actual.Raw) != `[10, [20, 30]]` || actual.Missing() || actual.Kind() != StartArray {
+		t.Errorf("** user.ids = %s", actual.Raw)
+	}
+	if actual := raw.Get("user").Get("ids").At(0); actual.Int64() != 10 || actual.Uint64() != 10 {
+		t.Errorf("** user.ids[0] = %s", actual.Raw)
+	}
+	if actual := raw.Get("user").Get("ids").Value(); !reflect.DeepEqual(actual, []any{10.0, []any{20.0, 30.0}}) {
+		t.Errorf("** user.ids = %v", actual)
+	}
+	if !raw.Get("user").Get("ok").Bool() || raw.Get("user").Get("f").Float() != 1.5 {
+		t.Errorf("** user.ok or user.f not decoded")
+	}
+	if !raw.Get("n").Null() || raw.Get("user").Null() {
+		t.Errorf("** n isn't null")
+	}
+	for name, missing := range map[string]Lookup{
+		"missing key":        raw.Get("x"),
+		"key of scalar":      raw.Get("n").Get("x"),
+		"key of array":       raw.Get("user").Get("ids").Get("0"),
+		"index of object":    raw.Get("user").At(0),
+		"index out of range": raw.Get("user").Get("ids").At(2),
+		"negative index":     raw.Get("user").Get("ids").At(-1),
+		"chained missing":    raw.Get("x").Get("y").At(3),
+	} {
+		if missing.Raw != nil || !missing.Missing() || missing.Kind() != EOF {
+			t.Errorf("** %s = %s, wanted missing", name, missing.Raw)
+		}
+		if missing.Str() != "" || missing.Int() != 0 || missing.Int64() != 0 || missing.Uint64() != 0 ||
+			missing.Float() != 0 || missing.Bool() || missing.Null() || missing.Value() != nil {
+			t.Errorf("** %s didn't read as zero values", name)
+		}
+	}
+	if actual := raw.Get("x").Get("y").Int(); actual != 0 {
+		t.Errorf("** x.y = %d, wanted 0", actual)
+	}
+	if string(raw) == "" || raw.Peek() != StartObject {
+		t.Errorf("** Get consumed input")
+	}
+	ensurePanic(t, func() { raw.Get("user").Get("name").Int() }, `unexpected JSON: "Ann"`)
+}
+
+func TestPointerMany(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		ptrs     []string
+		expected []string // "" for nil
+	}{
+		{`none`, `{"a": 1}`, nil, []string{}},
+		{`empty input`, ` `, []string{"", "/a"}, []string{"", ""}},
+		{`all malformed`, `{"a": 1}`, []string{"a"}, []string{""}},
+		{`same pointer twice`, `{"a": 1}`, []string{"/a", "/a"}, []string{"1", "1"}},
+		{`parent and child`, `{"a": {"b": [1, 2]}}`, []string{"/a/b/1", "/a", "/a/b"}, []string{"2", `{"b": [1, 2]}`, "[1, 2]"}},
+		{`duplicate keys`, `{"a": {"b": 1}, "a": {"b": 2, "c": 3}}`, []string{"/a/b", "/a/c", "/a"}, []string{"1", "", `{"b": 1}`}},
+		{`through a scalar`, `{"a": 1}`, []string{"/a/b", "/a"}, []string{"", "1"}},
+		{`array indexes`, `[[0, 1], [2, 3]]`, []string{"/1/0", "/0/1", "/x", "/-"}, []string{"2", "1", "", ""}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := Raw(test.input).PointerMany(test.ptrs)
+			if len(actual) != len(test.expected) {
+				t.Fatalf("** PointerMany(%q) returned %d tokens, wanted %d", test.ptrs, len(actual), len(test.expected))
+			}
+			for i, token := range actual {
+				if string(token) != test.expected[i] || (token == nil) != (test.expected[i] == "") {
+					t.Errorf("** PointerMany(%q)[%d] = %s, wanted %s", test.ptrs, i, token, test.expected[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+//go:build tinyjson_poison
+
+// Debug mode for catching strings that outlive their input, see PoisonAliases.
+
+package tinyjson
+
+import (
+	"sync"
+	"unsafe"
+)
+
+const poisonEnabled = true
+
+var poison struct {
+	sync.Mutex
+	bufs [][]byte
+}
+
+// aliased returns a tracked copy of s, which PoisonAliases overwrites.
+func aliased(s string) string {
+	if s == "" {
+		return s
+	}
+	buf := []byte(s)
+	poison.Lock()
+	poison.bufs = append(poison.bufs, buf)
+	poison.Unlock()
+	return unsafe.String(&buf[0], len(buf))
+}
+
+// PoisonAliases overwrites every string returned so far that would point
+// into the input in a normal build with 0xFF bytes. Call it wherever the
+// input is reused or released, e.g. before reading the next message into the
+// same buffer, and build the tests with -tags tinyjson_poison: code that
+// kept such a string without copying it then sees garbage instead of
+// silently getting whatever the buffer holds next. Poisoned map keys also
+// break lookups in their maps. A no-op in normal builds.
+//
+// The tracked strings are only released here, so a poison build that never
+// calls it keeps every one of them, growing without bound; the list is
+// shared by all goroutines and guarded by a mutex.
+func PoisonAliases() {
+	poison.Lock()
+	defer poison.Unlock()
+	for _, buf := range poison.bufs {
+		for i := range buf {
+			buf[i] = 0xFF
+		}
+	}
+	poison.bufs = nil
+}
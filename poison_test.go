@@ -0,0 +1,27 @@
+package tinyjson
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPoisonAliases(t *testing.T) {
+	m := raw(`{"key": "value"}`).Value().(map[string]any)
+	aliased := []string{Token(`"abc"`).Str(), Token(`" padded "`).StrTrimmed(), Token(`42`).Str(), m["key"].(string)}
+	copied := []string{Token(`"esc\u0061ped"`).Str(), Token(`"\tpadded"`).StrTrimmed(), Token(`"safe"`).SafeStr()}
+	PoisonAliases()
+
+	expected := []string{"abc", "padded", "42", "value"}
+	if poisonEnabled {
+		for i, s := range expected {
+			expected[i] = strings.Repeat("\xff", len(s))
+		}
+	}
+	if !reflect.DeepEqual(aliased, expected) {
+		t.Errorf("** aliased strings after PoisonAliases = %q, wanted %q", aliased, expected)
+	}
+	if expected := []string{"escaped", "padded", "safe"}; !reflect.DeepEqual(copied, expected) {
+		t.Errorf("** copied strings after PoisonAliases = %q, wanted %q", copied, expected)
+	}
+}
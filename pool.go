@@ -0,0 +1,71 @@
+package tinyjson
+
+// Pool recycles the maps and slices built by Value and ValueIter, reducing
+// garbage when decoding many similar documents. Set Decoder.Pool, and hand
+// each result back via Release once nothing refers to it anymore:
+//
+//	pool := new(tinyjson.Pool)
+//	for _, doc := range docs {
+//		d := tinyjson.Decoder{Raw: doc, Pool: pool}
+//		v := d.Value()
+//		handle(v)
+//		pool.Release(v)
+//	}
+//
+// Released maps and slices get cleared and reused by later decoding, so
+// keeping a reference to any part of a released value is a bug. A Pool is
+// not safe for concurrent use; give each goroutine its own.
+type Pool struct {
+	maps   []map[string]any
+	slices [][]any
+}
+
+// Release returns the maps and slices of v, as produced by Value, to the pool,
+// recursively. Other values are ignored.
+func (p *Pool) Release(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		if v == nil {
+			return
+		}
+		for k, item := range v {
+			p.Release(item)
+			delete(v, k)
+		}
+		p.maps = append(p.maps, v)
+	case []any:
+		if v == nil {
+			return
+		}
+		for i, item := range v {
+			p.Release(item)
+			v[i] = nil
+		}
+		p.slices = append(p.slices, v[:0])
+	}
+}
+
+func (p *Pool) newMap() map[string]any {
+	if p != nil {
+		if n := len(p.maps); n > 0 {
+			m := p.maps[n-1]
+			p.maps[n-1] = nil
+			p.maps = p.maps[:n-1]
+			return m
+		}
+	}
+	return make(map[string]any)
+}
+
+// newSlice returns an empty slice with spare capacity, or nil if none is pooled.
+func (p *Pool) newSlice() []any {
+	if p != nil {
+		if n := len(p.slices); n > 0 {
+			s := p.slices[n-1]
+			p.slices[n-1] = nil
+			p.slices = p.slices[:n-1]
+			return s
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package tinyjson
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	docs := []string{
+		`{"a": [1, {"b": "c"}], "d": {}}`,
+		`[[], [1, 2, 3], {"x": null}]`,
+		`{"a": [true], "e": [{"f": []}]}`,
+		`"scalar"`,
+	}
+	for name, value := range map[string]func(d *Decoder) any{"Value": (*Decoder).Value, "ValueIter": (*Decoder).ValueIter} {
+		t.Run(name, func(t *testing.T) {
+			pool := new(Pool)
+			for round := 0; round < 3; round++ {
+				for _, doc := range docs {
+					d := Decoder{Raw: Raw(doc), Pool: pool}
+					actual := value(&d)
+					expected := raw(doc).Value()
+					if !reflect.DeepEqual(actual, expected) {
+						t.Errorf("** round %d: %s(%s) = %v, wanted %v", round, name, doc, actual, expected)
+					}
+					pool.Release(actual)
+				}
+			}
+		})
+	}
+}
+
+func TestPoolReuse(t *testing.T) {
+	pool := new(Pool)
+	d := Decoder{Raw: Raw(`{"a": [1, 2]}`), Pool: pool}
+	v := d.Value().(map[string]any)
+	m, s := reflect.ValueOf(v).Pointer(), reflect.ValueOf(v["a"]).Pointer()
+	pool.Release(v)
+	if len(v) != 0 {
+		t.Errorf("** released map not cleared: %v", v)
+	}
+
+	d = Decoder{Raw: Raw(`{"b": [3]}`), Pool: pool}
+	v = d.Value().(map[string]any)
+	if reflect.ValueOf(v).Pointer() != m || reflect.ValueOf(v["b"]).Pointer() != s {
+		t.Errorf("** pooled map and slice not reused")
+	}
+	if !reflect.DeepEqual(v, map[string]any{"b": []any{3.0}}) {
+		t.Errorf("** got %v from reused containers", v)
+	}
+
+	pool.Release(nil)
+	pool.Release([]any(nil))
+	pool.Release(map[string]any(nil))
+	pool.Release("x")
+	if len(pool.maps) != 0 || len(pool.slices) != 0 {
+		t.Errorf("** non-containers added to the pool")
+	}
+	d = Decoder{Raw: Raw(`{"a": 1}`), Pool: pool}
+	if v := d.Value(); !reflect.DeepEqual(v, map[string]any{"a": 1.0}) {
+		t.Errorf("** got %v after releasing a nil map", v)
+	}
+}
+
+func TestPoolAllocs(t *testing.T) {
+	const doc = `{"a": ["x", "y", "z"], "b": {"c": true, "d": null}}`
+	unpooled := testing.AllocsPerRun(100, func() {
+		raw(doc).Value()
+	})
+	pool := new(Pool)
+	pooled := testing.AllocsPerRun(100, func() {
+		d := Decoder{Raw: Raw(doc), Pool: pool}
+		pool.Release(d.Value())
+	})
+	if pooled >= unpooled {
+		t.Errorf("** pooled Value made %v allocations, unpooled %v", pooled, unpooled)
+	}
+}
@@ -0,0 +1,58 @@
+package tinyjson
+
+// Presence records which fields of an object were present, and which of them
+// were null, for up to 64 fields numbered by the caller, to tell {} and
+// {"port": null} apart, e.g. when merging a config override:
+//
+//	const (
+//		fieldHost = iota
+//		fieldPort
+//	)
+//	var p tinyjson.Presence
+//	for key := raw.StartObject(); key != nil; key = raw.ContinueObject() {
+//		switch key.Str() {
+//		case "host":
+//			if p.Set(fieldHost, raw.Null()) {
+//				c.Host = raw.Str()
+//			}
+//		case "port":
+//			if p.Set(fieldPort, raw.Null()) {
+//				c.Port = raw.Int()
+//			}
+//		default:
+//			raw.Skip()
+//		}
+//	}
+//	if !p.Has(fieldPort) {
+//		c.Port = base.Port // absent: keep the base value
+//	} else if p.IsNull(fieldPort) {
+//		c.Port = 0 // null: reset to the default
+//	}
+//
+// The zero value has no fields present.
+type Presence struct {
+	present, null uint64
+}
+
+// Set records field i as present, and as null if null is true, returning
+// whether a non-null value follows, which must then be decoded.
+func (p *Presence) Set(i int, null bool) bool {
+	bit := presenceBit(i)
+	p.present |= bit
+	if null {
+		p.null |= bit
+	} else {
+		p.null &^= bit
+	}
+	return !null
+}
+
+func (p Presence) Has(i int) bool    { return p.present&presenceBit(i) != 0 } // Has reports whether field i was present, null or not
+func (p Presence) IsNull(i int) bool { return p.null&presenceBit(i) != 0 }    // IsNull reports whether field i was present and null
+
+func presenceBit(i int) uint64 {
+	if i < 0 || i >= 64 {
+		panic("tinyjson: Presence field number out of range")
+	}
+	return 1 << i
+}
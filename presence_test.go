@@ -0,0 +1,68 @@
+package tinyjson
+
+import "testing"
+
+func TestPresence(t *testing.T) {
+	const (
+		fieldHost = iota
+		fieldPort
+		fieldUser
+		fieldLast = 63
+	)
+	tests := []struct {
+		input        string
+		has, null    [4]bool
+		expectedPort int
+		expectedHost string
+		expectedLast bool
+	}{
+		{`{}`, [4]bool{}, [4]bool{}, 0, "", false},
+		{`{"port": null}`, [4]bool{false, true}, [4]bool{false, true}, 0, "", false},
+		{`{"port": 80, "host": "x", "last": true}`, [4]bool{true, true, false, true}, [4]bool{}, 80, "x", true},
+		{`{"host": null, "host": "y", "user": null}`, [4]bool{true, false, true}, [4]bool{false, false, true}, 0, "y", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			raw := Raw(test.input)
+			var p Presence
+			var port int
+			var host string
+			var last bool
+			for key := raw.StartObject(); key != nil; key = raw.ContinueObject() {
+				switch key.Str() {
+				case "host":
+					if p.Set(fieldHost, raw.Null()) {
+						host = raw.Str()
+					}
+				case "port":
+					if p.Set(fieldPort, raw.Null()) {
+						port = raw.Int()
+					}
+				case "user":
+					if p.Set(fieldUser, raw.Null()) {
+						raw.Skip()
+					}
+				case "last":
+					if p.Set(fieldLast, raw.Null()) {
+						last = raw.Bool()
+					}
+				}
+			}
+			raw.EnsureEOF()
+
+			for k, i := range []int{fieldHost, fieldPort, fieldUser, fieldLast} {
+				if p.Has(i) != test.has[k] || p.IsNull(i) != test.null[k] {
+					t.Errorf("** field %d: Has = %v, IsNull = %v, wanted %v, %v", i, p.Has(i), p.IsNull(i), test.has[k], test.null[k])
+				}
+			}
+			if port != test.expectedPort || host != test.expectedHost || last != test.expectedLast {
+				t.Errorf("** decoded %d, %q, %v", port, host, last)
+			}
+		})
+	}
+
+	var p Presence
+	ensurePanic(t, func() { p.Set(64, false) }, "tinyjson: Presence field number out of range")
+	ensurePanic(t, func() { p.Has(-1) }, "tinyjson: Presence field number out of range")
+}
@@ -0,0 +1,178 @@
+package tinyjson
+
+// StateMachine tokenizes JSON pushed into it one byte at a time, for input
+// that trickles in with no way to block for more, like a protocol over a
+// serial line. Each complete token is reported as an Event; partial strings,
+// numbers and literals are buffered inside the machine until they complete.
+//
+//	var sm tinyjson.StateMachine
+//	for {
+//		ev, ok := sm.Feed(uart.ReadByte())
+//		for ; ok; ev, ok = sm.More() {
+//			handle(ev)
+//			if ev.Depth == 0 && ev.Token.Kind() == tinyjson.EndObject {
+//				// a complete message has been received
+//			}
+//		}
+//	}
+//
+// Like Raw, it is lenient, doesn't match brackets or check for commas, and
+// panics on invalid input, after which Reset must be called to start over.
+// A number can only be completed by the byte that follows it, which must be
+// whitespace or punctuation. Byte order marks are not supported.
+//
+// The zero value is ready to use.
+type StateMachine struct {
+	// MaxToken, if positive, limits the length of strings (including the
+	// quotes) and numbers in bytes. The buffer is reused from token to token,
+	// so it only grows until it fits the longest one.
+	MaxToken int
+
+	buf   []byte
+	lit   Token // the literal being read
+	state smState
+	depth int
+	punct [1]byte
+	held  Event
+}
+
+// Event is a token completed by a StateMachine. Token points into the
+// machine's buffer and is only valid until the next call to Feed.
+type Event struct {
+	Token Token
+	Depth int // the nesting level; brackets are at the level of their container
+}
+
+type smState byte
+
+const (
+	smIdle smState = iota
+	smString
+	smEscape
+	smNumber
+	smLiteral
+)
+
+// Feed pushes the next byte of input, returning an event if it completes a
+// token. A byte that ends a number can complete two tokens; call More after
+// every Feed until it returns false.
+func (sm *StateMachine) Feed(b byte) (Event, bool) {
+	sm.held = Event{}
+	switch sm.state {
+	case smString:
+		sm.append(b)
+		if b == '\\' {
+			sm.state = smEscape
+		} else if b == '"' {
+			return sm.emit()
+		}
+		return Event{}, false
+	case smEscape:
+		sm.append(b)
+		sm.state = smString
+		return Event{}, false
+	case smLiteral:
+		if b != sm.lit[len(sm.buf)] {
+			panic("invalid JSON")
+		}
+		sm.buf = append(sm.buf, b)
+		if len(sm.buf) == len(sm.lit) {
+			return sm.emit()
+		}
+		return Event{}, false
+	case smNumber:
+		if kindByByte[b] == Number || b == 'e' || b == 'E' {
+			sm.append(b)
+			return Event{}, false
+		}
+		switch kindByByte[b] {
+		case String, True, False, Null:
+			panic("invalid JSON")
+		}
+		ev, _ := sm.emit()
+		sm.held, _ = sm.start(b)
+		return ev, true
+	default:
+		return sm.start(b)
+	}
+}
+
+// More returns the second token completed by the last Feed, if any.
+func (sm *StateMachine) More() (Event, bool) {
+	ev := sm.held
+	sm.held = Event{}
+	return ev, ev.Token != nil
+}
+
+// End signals the end of input, returning the number being read, if any,
+// which no following byte has completed. Panics if the input ends inside
+// a token or a container.
+func (sm *StateMachine) End() (Event, bool) {
+	if sm.state != smIdle && sm.state != smNumber || sm.depth > 0 {
+		panic("unexpected end of JSON")
+	}
+	if sm.state == smNumber {
+		return sm.emit()
+	}
+	return Event{}, false
+}
+
+// Reset discards the state, e.g. after a panic, keeping MaxToken and the buffer.
+func (sm *StateMachine) Reset() {
+	*sm = StateMachine{MaxToken: sm.MaxToken, buf: sm.buf[:0]}
+}
+
+func (sm *StateMachine) start(b byte) (Event, bool) {
+	if isWhitespace(b) {
+		return Event{}, false
+	}
+	switch k := kindByByte[b]; k {
+	case String, Number:
+		sm.buf = sm.buf[:0]
+		sm.append(b)
+		sm.state = smString
+		if k == Number {
+			sm.state = smNumber
+		}
+	case True, False, Null:
+		sm.lit = NullToken
+		if k == True {
+			sm.lit = TrueToken
+		} else if k == False {
+			sm.lit = FalseToken
+		}
+		sm.buf = append(sm.buf[:0], b)
+		sm.state = smLiteral
+	case StartObject, StartArray:
+		sm.depth++
+		return sm.punctuation(b, sm.depth-1), true
+	case EndObject, EndArray:
+		if sm.depth == 0 {
+			panic("invalid JSON")
+		}
+		sm.depth--
+		return sm.punctuation(b, sm.depth), true
+	case Colon, Comma:
+		return sm.punctuation(b, sm.depth), true
+	default:
+		panic("invalid JSON")
+	}
+	return Event{}, false
+}
+
+func (sm *StateMachine) append(b byte) {
+	if sm.MaxToken > 0 && len(sm.buf) >= sm.MaxToken {
+		panic("invalid JSON: token exceeds maximum length")
+	}
+	sm.buf = append(sm.buf, b)
+}
+
+func (sm *StateMachine) emit() (Event, bool) {
+	sm.state = smIdle
+	return Event{Token: Token(sm.buf), Depth: sm.depth}, true
+}
+
+func (sm *StateMachine) punctuation(b byte, depth int) Event {
+	sm.punct[0] = b
+	return Event{Token: Token(sm.punct[:]), Depth: depth}
+}
@@ -0,0 +1,124 @@
+package tinyjson
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestStateMachine(t *testing.T) {
+	tests := []string{
+		`{"a": [1, -2.5e3, "x\"y\\", true, false, null], "b": {}}`,
+		`[1,[2],{"c":3}]`,
+		`42`,
+		` "é" `,
+		"[\r\n\t]",
+		``,
+	}
+
+	for _, input := range tests {
+		t.Run(input, func(t *testing.T) {
+			var sm StateMachine
+			var actual []string
+			var depths []int
+			note := func(ev Event) {
+				actual = append(actual, string(ev.Token))
+				depths = append(depths, ev.Depth)
+			}
+			for _, b := range []byte(input) {
+				ev, ok := sm.Feed(b)
+				for ; ok; ev, ok = sm.More() {
+					note(ev)
+				}
+			}
+			if ev, ok := sm.End(); ok {
+				note(ev)
+			}
+			if expected := allTokens(input); !reflect.DeepEqual(actual, expected) {
+				t.Errorf("** tokens = %q, wanted %q", actual, expected)
+			}
+			depth := 0
+			for i, tok := range actual {
+				if k := Token(tok).Kind(); k == EndObject || k == EndArray {
+					depth--
+				}
+				if depths[i] != depth {
+					t.Errorf("** depth of token %d %s = %d, wanted %d", i, tok, depths[i], depth)
+				}
+				if k := Token(tok).Kind(); k == StartObject || k == StartArray {
+					depth++
+				}
+			}
+		})
+	}
+}
+
+func TestStateMachineMaxToken(t *testing.T) {
+	sm := StateMachine{MaxToken: 5}
+	feed := func(s string) (tokens []string) {
+		for _, b := range []byte(s) {
+			ev, ok := sm.Feed(b)
+			for ; ok; ev, ok = sm.More() {
+				tokens = append(tokens, string(ev.Token))
+			}
+		}
+		return tokens
+	}
+	if actual := feed(`["abc", 123, true]`); !reflect.DeepEqual(actual, []string{`[`, `"abc"`, `,`, `123`, `,`, `true`, `]`}) {
+		t.Errorf("** tokens = %q", actual)
+	}
+	ensurePanic(t, func() { feed(`"abcd"`) }, "invalid JSON: token exceeds maximum length")
+
+	sm.Reset()
+	if actual := feed(`12345 `); !reflect.DeepEqual(actual, []string{`12345`}) {
+		t.Errorf("** tokens after Reset = %q", actual)
+	}
+	ensurePanic(t, func() { feed(`123456`) }, "invalid JSON: token exceeds maximum length")
+}
+
+func TestStateMachineInvalid(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`tru3`, "invalid JSON"},
+		{`nul `, "invalid JSON"},
+		{`1"x"`, "invalid JSON"},
+		{`1true`, "invalid JSON"},
+		{`]`, "invalid JSON"},
+		{`[1 @`, "invalid JSON"},
+		{`"abc`, "unexpected end of JSON"},
+		{`"abc\`, "unexpected end of JSON"},
+		{`fals`, "unexpected end of JSON"},
+		{`{"a": 1`, "unexpected end of JSON"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			ensurePanic(t, func() {
+				var sm StateMachine
+				for _, b := range []byte(test.input) {
+					sm.Feed(b)
+				}
+				sm.End()
+			}, test.expected)
+		})
+	}
+}
+
+func BenchmarkStateMachine(b *testing.B) {
+	var data []byte
+	for i := 0; i < 100; i++ {
+		data = append(data, `{"id": `+strconv.Itoa(i)+`, "name": "item", "tags": ["a", "b"], "ok": true}`...)
+	}
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	var sm StateMachine
+	for i := 0; i < b.N; i++ {
+		for _, c := range data {
+			_, ok := sm.Feed(c)
+			for ; ok; _, ok = sm.More() {
+			}
+		}
+	}
+}
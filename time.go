@@ -0,0 +1,89 @@
+//go:build !tinyjson_notime
+
+// Accessors that depend on the time package. Build with -tags tinyjson_notime
+// to leave them (and the time package) out of minimal binaries.
+
+package tinyjson
+
+import (
+	"strconv"
+	"time"
+)
+
+// Duration returns a time.Duration parsed from a JSON string like "30s" via
+// time.ParseDuration, panics if impossible.
+func (t Token) Duration() time.Duration {
+	if t.Kind() == String {
+		if v, err := time.ParseDuration(unquoteString(t)); err == nil {
+			return v
+		}
+	}
+	panic(unexpected(t))
+}
+
+// Time returns a time.Time parsed from an RFC 3339 JSON string like
+// "2024-01-02T15:04:05Z" (fractional seconds allowed), panics if impossible.
+func (t Token) Time() time.Time { return t.parseTime(time.RFC3339Nano) }
+
+// Date returns a time.Time parsed from a JSON string like "2024-01-02", at
+// midnight UTC, panics if impossible.
+func (t Token) Date() time.Time { return t.parseTime(time.DateOnly) }
+
+// TimeOfDay returns a time.Time parsed from a JSON string like "15:04:05", on
+// January 1 of year 0 UTC, panics if impossible.
+func (t Token) TimeOfDay() time.Time { return t.parseTime(time.TimeOnly) }
+
+// UnixTime returns the UTC time.Time of a number of seconds since the Unix
+// epoch, like 1700000000 or 1700000000.25, panics if not a number or if beyond
+// the range of int64.
+func (t Token) UnixTime() time.Time { return t.unixTime(time.Second) }
+
+// UnixMilliTime is like UnixTime, but for a number of milliseconds.
+func (t Token) UnixMilliTime() time.Time { return t.unixTime(time.Millisecond) }
+
+func (t Token) unixTime(unit time.Duration) time.Time {
+	if t.Kind() == Number {
+		whole, err := strconv.ParseInt(t.Raw(), 10, 64)
+		var frac float64
+		if err != nil {
+			var v float64
+			v, err = strconv.ParseFloat(t.Raw(), 64)
+			if err == nil && !(v >= -1<<63 && v < 1<<63) {
+				panic("unexpected JSON: " + t.Raw() + ": Unix time out of range")
+			}
+			whole = int64(v)
+			frac = v - float64(whole)
+		}
+		if err == nil {
+			extra := time.Duration(frac * float64(unit))
+			if unit == time.Second {
+				return time.Unix(whole, 0).Add(extra).UTC()
+			}
+			return time.UnixMilli(whole).Add(extra).UTC()
+		}
+	}
+	panic(unexpected(t))
+}
+
+func (t Token) parseTime(layout string) time.Time {
+	if t.Kind() == String {
+		if v, err := time.Parse(layout, unquoteString(t)); err == nil {
+			return v
+		}
+	}
+	panic(unexpected(t))
+}
+
+func (raw *Raw) Duration() time.Duration  { return raw.Next().Duration() }      // Duration returns .Next().Duration()
+func (raw *Raw) Time() time.Time          { return raw.Next().Time() }          // Time returns .Next().Time()
+func (raw *Raw) Date() time.Time          { return raw.Next().Date() }          // Date returns .Next().Date()
+func (raw *Raw) TimeOfDay() time.Time     { return raw.Next().TimeOfDay() }     // TimeOfDay returns .Next().TimeOfDay()
+func (raw *Raw) UnixTime() time.Time      { return raw.Next().UnixTime() }      // UnixTime returns .Next().UnixTime()
+func (raw *Raw) UnixMilliTime() time.Time { return raw.Next().UnixMilliTime() } // UnixMilliTime returns .Next().UnixMilliTime()
+
+func (d *Decoder) Duration() time.Duration  { defer d.catch(); return d.Raw.next(d).Duration() }      // Duration returns .Next().Duration()
+func (d *Decoder) Time() time.Time          { defer d.catch(); return d.Raw.next(d).Time() }          // Time returns .Next().Time()
+func (d *Decoder) Date() time.Time          { defer d.catch(); return d.Raw.next(d).Date() }          // Date returns .Next().Date()
+func (d *Decoder) TimeOfDay() time.Time     { defer d.catch(); return d.Raw.next(d).TimeOfDay() }     // TimeOfDay returns .Next().TimeOfDay()
+func (d *Decoder) UnixTime() time.Time      { defer d.catch(); return d.Raw.next(d).UnixTime() }      // UnixTime returns .Next().UnixTime()
+func (d *Decoder) UnixMilliTime() time.Time { defer d.catch(); return d.Raw.next(d).UnixMilliTime() } // UnixMilliTime returns .Next().UnixMilliTime()
@@ -0,0 +1,103 @@
+//go:build !tinyjson_notime
+
+package tinyjson
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected time.Duration
+	}{
+		{`seconds`, `"30s"`, 30 * time.Second},
+		{`compound`, `"1h2m3.5s"`, time.Hour + 2*time.Minute + 3500*time.Millisecond},
+		{`negative`, `"-150ms"`, -150 * time.Millisecond},
+		{`zero`, `"0"`, 0},
+		{`escaped`, `"5\u00b5s"`, 5 * time.Microsecond},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := raw(test.input).Duration()
+			if actual != test.expected {
+				t.Errorf("** Raw.Duration(%s) = %v, wanted %v", test.input, actual, test.expected)
+			}
+			d := Decoder{Raw: Raw(test.input)}
+			if actual := d.Duration(); actual != test.expected {
+				t.Errorf("** Decoder.Duration(%s) = %v, wanted %v", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		f        func(raw *Raw) time.Time
+		g        func(d *Decoder) time.Time
+		expected time.Time
+	}{
+		{`Time`, `"2024-01-02T15:04:05Z"`, (*Raw).Time, (*Decoder).Time, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{`Time with fraction`, `"2024-01-02T15:04:05.25Z"`, (*Raw).Time, (*Decoder).Time, time.Date(2024, 1, 2, 15, 4, 5, 250000000, time.UTC)},
+		{`Time with offset`, `"2024-01-02T18:04:05+03:00"`, (*Raw).Time, (*Decoder).Time, time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{`Date`, `"2024-01-02"`, (*Raw).Date, (*Decoder).Date, time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{`TimeOfDay`, `"15:04:05"`, (*Raw).TimeOfDay, (*Decoder).TimeOfDay, time.Date(0, 1, 1, 15, 4, 5, 0, time.UTC)},
+		{`UnixTime`, `1700000000`, (*Raw).UnixTime, (*Decoder).UnixTime, time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)},
+		{`UnixTime negative`, `-86400`, (*Raw).UnixTime, (*Decoder).UnixTime, time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{`UnixTime fraction`, `1700000000.25`, (*Raw).UnixTime, (*Decoder).UnixTime, time.Date(2023, 11, 14, 22, 13, 20, 250000000, time.UTC)},
+		{`UnixTime exponent`, `1.7e9`, (*Raw).UnixTime, (*Decoder).UnixTime, time.Date(2023, 11, 14, 22, 13, 20, 0, time.UTC)},
+		{`UnixMilliTime`, `1700000000123`, (*Raw).UnixMilliTime, (*Decoder).UnixMilliTime, time.Date(2023, 11, 14, 22, 13, 20, 123000000, time.UTC)},
+		{`UnixMilliTime fraction`, `1700000000123.5`, (*Raw).UnixMilliTime, (*Decoder).UnixMilliTime, time.Date(2023, 11, 14, 22, 13, 20, 123500000, time.UTC)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.f(raw(test.input))
+			if !actual.Equal(test.expected) {
+				t.Errorf("** Raw.%s(%s) = %v, wanted %v", test.name, test.input, actual, test.expected)
+			}
+			d := Decoder{Raw: Raw(test.input)}
+			if actual := test.g(&d); !actual.Equal(test.expected) {
+				t.Errorf("** Decoder.%s(%s) = %v, wanted %v", test.name, test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestUnixTimeLocation(t *testing.T) {
+	if loc := raw(`0`).UnixTime().Location(); loc != time.UTC {
+		t.Errorf("** UnixTime location is %v, wanted UTC", loc)
+	}
+}
+
+func TestTimePanics(t *testing.T) {
+	tests := []struct {
+		name     string
+		f        func()
+		expected string
+	}{
+		{`number cannot Duration`, func() { raw(`30`).Duration() }, "unexpected JSON: 30"},
+		{`invalid Duration`, func() { raw(`"30 seconds"`).Duration() }, `unexpected JSON: "30 seconds"`},
+		{`string cannot UnixTime`, func() { raw(`"1700000000"`).UnixTime() }, `unexpected JSON: "1700000000"`},
+		{`invalid UnixMilliTime`, func() { raw(`1e999`).UnixMilliTime() }, `unexpected JSON: 1e999`},
+		{`huge UnixTime`, func() { raw(`1e300`).UnixTime() }, `unexpected JSON: 1e300: Unix time out of range`},
+		{`huge negative UnixTime`, func() { raw(`-1e300`).UnixTime() }, `unexpected JSON: -1e300: Unix time out of range`},
+		{`UnixMilliTime beyond int64`, func() { raw(`9223372036854775808.0`).UnixMilliTime() }, `unexpected JSON: 9223372036854775808.0: Unix time out of range`},
+		{`number cannot Time`, func() { raw(`0`).Time() }, "unexpected JSON: 0"},
+		{`Date cannot Time`, func() { raw(`"2024-01-02"`).Time() }, `unexpected JSON: "2024-01-02"`},
+		{`Time cannot Date`, func() { raw(`"2024-01-02T15:04:05Z"`).Date() }, `unexpected JSON: "2024-01-02T15:04:05Z"`},
+		{`invalid Date`, func() { raw(`"2024-13-02"`).Date() }, `unexpected JSON: "2024-13-02"`},
+		{`invalid TimeOfDay`, func() { raw(`"25:00:00"`).TimeOfDay() }, `unexpected JSON: "25:00:00"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ensurePanic(t, test.f, test.expected)
+		})
+	}
+}
@@ -1,10 +1,28 @@
 // tinyjson is a minimalistic JSON tokenizer/parser for producing small tinygo
 // binaries. It assumes a valid JSON input already available as a []byte.
+//
+// # Aliasing
+//
+// tinyjson never writes into its input, but avoids copying it, so many
+// results point into the input and are only valid for as long as it is, e.g.
+// while a memory-mapped file stays mapped:
+//
+//   - Tokens, Raw values, and the results of Token.Raw and RawStr always
+//     point into the input;
+//   - strings returned by Str, StrTrimmed, Key, Enum and Value (including
+//     the keys of its maps) point into the input unless they had escape
+//     sequences to decode;
+//   - numbers, booleans, times and other non-string results never do.
+//
+// Use SafeStr, strings.Clone or Decoder.InternKeys for strings that must
+// outlive the input, and PoisonAliases to find the ones that don't.
 package tinyjson
 
 import (
+	"reflect"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 	"unsafe"
 )
 
@@ -35,6 +53,7 @@ var kindByByte = [256]Kind{
 	'[': StartArray,
 	']': EndArray,
 	'"': String,
+	'+': Number,
 	'-': Number,
 	'.': Number,
 	'0': Number,
@@ -54,10 +73,12 @@ var kindByByte = [256]Kind{
 	',': Comma,
 }
 
+// Canonical tokens for the JSON literals, for comparing and emitting tokens
+// without spelling them out. They must not be modified.
 var (
-	trueToken  = Token("true")
-	falseToken = Token("false")
-	nullToken  = Token("null")
+	TrueToken  = Token("true")
+	FalseToken = Token("false")
+	NullToken  = Token("null")
 )
 
 // Raw returns the source JSON string of the token.
@@ -68,6 +89,12 @@ func (t Token) Raw() string {
 	return unsafe.String(&t[0], len(t))
 }
 
+// AppendTo appends the source JSON of the token to dst verbatim, appending
+// nothing for EOF.
+func (t Token) AppendTo(dst []byte) []byte {
+	return append(dst, t...)
+}
+
 // Kind returns the kind of the token, generally its first character, 0 for EOF, '9' for numbers.
 func (t Token) Kind() Kind {
 	if t == nil {
@@ -90,10 +117,17 @@ func (t Token) Scalar() any {
 	case False:
 		return false
 	default:
-		panic("unexpected JSON: " + t.Raw())
+		panic(unexpected(t))
 	}
 }
 
+// ScalarPreserve is like Scalar, but returns integers that fit into int64 as
+// int64, so that printing values back doesn't lose precision. Other numbers
+// are float64, like in ValueTyped, including ±Inf for ones beyond its range.
+func (t Token) ScalarPreserve() any {
+	return (&Decoder{NumberParser: IntOrFloat}).scalar(t)
+}
+
 // Str returns an unquoted Go string value corresponding to this token, with
 // escape seqeuences handled. Returns an empty string for null or EOF, and
 // the original JSON strings for false, true and numbers. Panics otherwise.
@@ -104,40 +138,297 @@ func (t Token) Str() string {
 	case String:
 		return unquoteString(t)
 	case True, False, Number:
-		return t.Raw()
+		return aliased(t.Raw())
 	default:
-		panic("unexpected JSON: " + t.Raw())
+		panic(unexpected(t))
 	}
 }
 
-// Int returns an int value corresponding to this token, panics if impossible.
-func (t Token) Int() int {
-	if t.Kind() == Number {
-		if v, err := strconv.ParseInt(t.Raw(), 10, 0); err == nil {
-			return int(v)
+// SafeStr is like Str, but always returns a copy, which stays valid after
+// the input is modified or unmapped.
+func (t Token) SafeStr() string {
+	if t.HasEscape() {
+		return t.Str() // decoding escapes already copies
+	}
+	return strings.Clone(t.Str())
+}
+
+// StrTrimmed is like Str, but with leading and trailing ASCII whitespace
+// removed from string values, for sloppy sources that pad them. Escaped
+// whitespace, like \t, is trimmed too; like Str, it only copies strings
+// with escape sequences.
+func (t Token) StrTrimmed() string {
+	if t.Kind() != String {
+		return t.Str()
+	}
+	s := t[1 : len(t)-1]
+	if !hasEscape(s) {
+		s = trimSpace(s)
+		return aliased(unsafe.String(unsafe.SliceData(s), len(s)))
+	}
+	s = trimSpace(appendUnescaped(make([]byte, 0, len(s)), s)) // unescape first, so as not to split a lenient escape like "\ "
+	return unsafe.String(unsafe.SliceData(s), len(s))
+}
+
+func trimSpace(s []byte) []byte {
+	for len(s) > 0 && isSpace(s[0]) {
+		s = s[1:]
+	}
+	for len(s) > 0 && isSpace(s[len(s)-1]) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || (c >= '\t' && c <= '\r')
+}
+
+// RawStr returns the contents of a string token between the quotes, with
+// escape sequences kept as written, so that tools like formatters can
+// preserve them; Str decodes them. Panics if this is not a string token.
+func (t Token) RawStr() string {
+	if t.Kind() != String {
+		panic(unexpected(t))
+	}
+	return t.Raw()[1 : len(t)-1]
+}
+
+// HasEscape reports whether this is a string token containing escape
+// sequences. Str returns escape-free strings without copying, pointing into
+// the input, and allocates a new string otherwise. False for other kinds.
+func (t Token) HasEscape() bool {
+	return t.Kind() == String && hasEscape(t[1:len(t)-1])
+}
+
+// EscapeCount returns the number of escape sequences in a string token, like
+// 2 for "\u0061\n", without unescaping it. Panics if this is not a string token.
+func (t Token) EscapeCount() int {
+	if t.Kind() != String {
+		panic(unexpected(t))
+	}
+	n := 0
+	for i := 1; i < len(t)-1; i++ {
+		if t[i] == '\\' {
+			n++
+			i++ // skip the escaped character
+		}
+	}
+	return n
+}
+
+// StrAppend appends the value Str would return to dst, avoiding a separate
+// allocation for strings with escape sequences. If strict is set, it panics
+// on escape sequences RFC 8259 doesn't define, like \x, which are otherwise
+// decoded as the escaped character, the way Str does.
+func (t Token) StrAppend(dst []byte, strict bool) []byte {
+	switch t.Kind() {
+	case EOF, Null:
+		return dst
+	case String:
+		if strict {
+			checkString(t, "string")
 		}
+		return appendUnescaped(dst, t[1:len(t)-1])
+	case True, False, Number:
+		return append(dst, t...)
+	default:
+		panic(unexpected(t))
 	}
-	panic("unexpected JSON: " + t.Raw())
 }
 
+// Int returns an int value corresponding to this token, panics if impossible.
+// Like Int64, it accepts integers written with a fraction or exponent.
+func (t Token) Int() int { return int(t.intN(0)) }
+
 // Int returns an int64 value corresponding to this token, panics if impossible.
-func (t Token) Int64() int64 {
+// Integers written with a fraction or exponent, like 3.0, 1e3 or 1.5e1, are
+// accepted too, as APIs emit large integers in scientific notation; their
+// value is computed exactly, like IntExact does.
+func (t Token) Int64() int64 { return t.intN(64) }
+
+// Uint64 returns an uint64 value corresponding to this token, panics if
+// impossible. Like Int64, it accepts integers written with a fraction or
+// exponent, like 3.0 or 1e3.
+func (t Token) Uint64() uint64 { return t.uintN(64) }
+
+func (t Token) Int8() int8     { return int8(t.intN(8)) }     // Int8 is like Int64, but panics unless the value fits into int8
+func (t Token) Int16() int16   { return int16(t.intN(16)) }   // Int16 is like Int64, but panics unless the value fits into int16
+func (t Token) Int32() int32   { return int32(t.intN(32)) }   // Int32 is like Int64, but panics unless the value fits into int32
+func (t Token) Uint8() uint8   { return uint8(t.uintN(8)) }   // Uint8 is like Uint64, but panics unless the value fits into uint8
+func (t Token) Uint16() uint16 { return uint16(t.uintN(16)) } // Uint16 is like Uint64, but panics unless the value fits into uint16
+func (t Token) Uint32() uint32 { return uint32(t.uintN(32)) } // Uint32 is like Uint64, but panics unless the value fits into uint32
+
+// FitsInt reports whether this is an integer number token that Int64 accepts
+// and that fits into a signed integer of the given number of bits (or into
+// int if bits is 0), e.g. FitsInt(16) before calling Int16, for reporting an
+// out-of-range value in domain terms instead of via a panic.
+func (t Token) FitsInt(bits int) bool {
+	_, ok := t.parseIntN(bits)
+	return ok
+}
+
+// FitsUint is like FitsInt, but for unsigned integers and Uint64.
+func (t Token) FitsUint(bits int) bool {
+	_, ok := t.parseUintN(bits)
+	return ok
+}
+
+// intN returns the value of an integer number token fitting into bitSize bits
+// (or into int if bitSize is 0), including one like 4.2e1, panics otherwise.
+func (t Token) intN(bitSize int) int64 {
+	if v, ok := t.parseIntN(bitSize); ok {
+		return v
+	}
+	panic(unexpected(t))
+}
+
+func (t Token) parseIntN(bitSize int) (int64, bool) {
 	if t.Kind() == Number {
-		if v, err := strconv.ParseInt(t.Raw(), 10, 0); err == nil {
-			return v
+		if v, err := strconv.ParseInt(t.Raw(), 10, bitSize); err == nil {
+			return v, true
 		}
+		if bitSize == 0 {
+			bitSize = strconv.IntSize
+		}
+		shift := 64 - bitSize
+		if v, integral, _ := decimalInt(t); integral && v<<shift>>shift == v {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+func (t Token) uintN(bitSize int) uint64 {
+	if v, ok := t.parseUintN(bitSize); ok {
+		return v
 	}
-	panic("unexpected JSON: " + t.Raw())
+	panic(unexpected(t))
 }
 
-// Int returns an uint64 value corresponding to this token, panics if impossible.
-func (t Token) Uint64() uint64 {
+func (t Token) parseUintN(bitSize int) (uint64, bool) {
 	if t.Kind() == Number {
-		if v, err := strconv.ParseUint(t.Raw(), 10, 0); err == nil {
-			return v
+		if v, err := strconv.ParseUint(strings.TrimPrefix(t.Raw(), "+"), 10, bitSize); err == nil {
+			return v, true
+		}
+		if bitSize == 0 {
+			bitSize = strconv.IntSize
+		}
+		s := t.Raw()
+		neg := s[0] == '-'
+		if s[0] == '-' || s[0] == '+' {
+			s = s[1:]
+		}
+		if u, integral, _ := decimalUint(s, ^uint64(0)>>(64-bitSize)); integral && (!neg || u == 0) {
+			return u, true
+		}
+	}
+	return 0, false
+}
+
+// IntExact returns the value of a number token as an int64 and true if it is
+// an integer within range, however it is written, like 3, 3.0, 1e3 or 1.5e1.
+// The value is computed from the decimal digits, never via float64. For a
+// number with a non-zero fractional part, like 3.7 or -35e-1, it returns the
+// value truncated toward zero (3 and -3) and false; for one outside the range
+// of int64, 0 and false. Panics if this is not a number token.
+func (t Token) IntExact() (int64, bool) {
+	v, integral, ok := decimalInt(t)
+	if !ok {
+		panic(unexpected(t))
+	}
+	return v, integral
+}
+
+// decimalInt returns the value of number token t truncated toward zero, and
+// whether it is an integer fitting into int64, in which case the value is
+// exact, or 0 and false if it doesn't fit. ok is false unless t is a number.
+func decimalInt(t Token) (v int64, integral, ok bool) {
+	if t.Kind() != Number {
+		return 0, false, false
+	}
+	s := t.Raw()
+	neg := s[0] == '-'
+	if s[0] == '-' || s[0] == '+' {
+		s = s[1:]
+	}
+	limit := uint64(1<<63 - 1)
+	if neg {
+		limit++
+	}
+	u, integral, ok := decimalUint(s, limit)
+	if neg {
+		return -int64(u), integral, ok
+	}
+	return int64(u), integral, ok
+}
+
+// decimalUint is like decimalInt for an unsigned number s, fitting into limit.
+func decimalUint(s string, limit uint64) (u uint64, integral, ok bool) {
+	var exp int64
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		var err error
+		exp, err = strconv.ParseInt(s[i+1:], 10, 32) // if out of range, ±MaxInt32, still exact enough
+		if err != nil && err.(*strconv.NumError).Err != strconv.ErrRange {
+			return 0, false, false
+		}
+		s = s[:i]
+	}
+	intPart, frac, _ := strings.Cut(s, ".")
+	if intPart == "" && frac == "" {
+		return 0, false, false
+	}
+
+	integral = true
+	k := int64(len(intPart)) + exp // the number of digits before the decimal point
+	var n int64
+	fits := true
+	for _, digits := range [2]string{intPart, frac} {
+		for _, c := range []byte(digits) {
+			if c < '0' || c > '9' {
+				return 0, false, false
+			}
+			if n < k {
+				u, fits = mulAdd10(u, c-'0', limit, fits)
+			} else if c != '0' {
+				integral = false
+			}
+			n++
 		}
 	}
-	panic("unexpected JSON: " + t.Raw())
+	for ; n < k && u != 0 && fits; n++ {
+		u, fits = mulAdd10(u, 0, limit, fits)
+	}
+	if !fits {
+		return 0, false, true
+	}
+	return u, integral, true
+}
+
+// mulAdd10 returns u*10+d and true, or false if that exceeds limit or if fits
+// is already false.
+func mulAdd10(u uint64, d byte, limit uint64, fits bool) (uint64, bool) {
+	if !fits || u > (limit-uint64(d))/10 {
+		return 0, false
+	}
+	return u*10 + uint64(d), true
+}
+
+// MaxSafeInteger is the largest integer n such that n and n+1 are both
+// exactly representable as float64, 2^53-1, like JavaScript's
+// Number.MAX_SAFE_INTEGER. Value returns numbers as float64, so integers
+// beyond it, like large IDs, may come back changed.
+const MaxSafeInteger = 1<<53 - 1
+
+// IsSafeInteger reports whether this is a number token written as an integer
+// (without a fraction, exponent or leading plus sign) between -MaxSafeInteger
+// and MaxSafeInteger, and so survives a round trip through float64 unchanged.
+func (t Token) IsSafeInteger() bool {
+	if t.Kind() != Number || t[0] == '+' {
+		return false
+	}
+	v, err := strconv.ParseInt(t.Raw(), 10, 64)
+	return err == nil && v >= -MaxSafeInteger && v <= MaxSafeInteger
 }
 
 // Int returns a float64 value corresponding to this token, panics if impossible.
@@ -147,7 +438,76 @@ func (t Token) Float() float64 {
 			return v
 		}
 	}
-	panic("unexpected JSON: " + t.Raw())
+	panic(unexpected(t))
+}
+
+// FloatFast returns the same result as Float, but computes numbers like 12.5
+// and -0.004, without an exponent and with at most 15 digits, using integer
+// math instead of strconv.ParseFloat, which is faster and never allocates,
+// including on tinygo. Other numbers fall back to Float.
+func (t Token) FloatFast() float64 {
+	i, n := 0, len(t)
+	neg := n > 0 && t[0] == '-'
+	if neg {
+		i++
+	}
+	var mant uint64
+	digits, frac := 0, -1
+	for ; i < n; i++ {
+		c := t[i]
+		if c >= '0' && c <= '9' {
+			mant = mant*10 + uint64(c-'0')
+			digits++
+			if frac >= 0 {
+				frac++
+			}
+		} else if c == '.' && frac < 0 && digits > 0 {
+			frac = 0
+		} else {
+			return t.Float()
+		}
+	}
+	if digits == 0 || digits > 15 || frac == 0 {
+		return t.Float()
+	}
+	// both values are exact, and so is the correctly rounded quotient
+	v := float64(mant)
+	if frac > 0 {
+		v /= float64Pow10[frac]
+	}
+	if neg {
+		v = -v
+	}
+	return v
+}
+
+var float64Pow10 = [...]float64{1, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9, 1e10, 1e11, 1e12, 1e13, 1e14, 1e15}
+
+// FloatLenient is like Float, but also accepts a string containing a number,
+// like "3.14", as some APIs send numbers that way. The string must hold just
+// a number as RFC 8259 writes it, not "+5", "Inf" or Go syntax like "0x10".
+func (t Token) FloatLenient() float64 {
+	if t.Kind() == String {
+		s := unquoteString(t)
+		num, rest := scanNumber(unsafe.Slice(unsafe.StringData(s), len(s)))
+		if len(num) > 0 && len(rest) == 0 && numberProblem(num) == "" {
+			if v, err := strconv.ParseFloat(s, 64); err == nil {
+				return v
+			}
+		}
+		panic(unexpected(t))
+	}
+	return t.Float()
+}
+
+// NumberCanonical returns the shortest string that parses back to the same
+// float64 as this number token, so that equal numbers like 1, 1.0 and 1e0 all
+// become "1"; panics if not a number. The formatting is the one Canonicalize
+// and AppendValue use: no exponent for magnitudes in [1e-6, 1e21), so
+// 100000000000 stays as is, while 1e21 becomes "1e+21" and 0.0000001 becomes
+// "1e-7". Numbers that differ beyond float64 precision map to the same string.
+func (t Token) NumberCanonical() string {
+	return string(appendFloat(nil, t.Float()))
 }
 
 // Int returns true or false value corresponding to this token, panics if impossible.
@@ -158,59 +518,210 @@ func (t Token) Bool() bool {
 	case False:
 		return false
 	default:
-		panic("unexpected JSON: " + t.Raw())
+		panic(unexpected(t))
 	}
 }
 
-func peekNextTokenKind(data []byte) (kind Kind, remainder []byte) {
-	start := 0
-	n := len(data)
-	for {
-		if start == n {
-			return EOF, nil
+// KeyIs reports whether this token is a string equal to s, i.e. whether
+// Str() == s, but without allocating unless the token contains escape
+// sequences. Escapes are honored, so "\u0061" is a. Returns false for
+// non-string tokens.
+func (t Token) KeyIs(s string) bool {
+	if t.Kind() != String {
+		return false
+	}
+	if inner := t[1 : len(t)-1]; !hasEscape(inner) {
+		return string(inner) == s
+	}
+	return unquoteString(t) == s
+}
+
+// Enum returns the unquoted string value of this token if it is one of allowed,
+// panics otherwise listing the allowed values.
+func (t Token) Enum(allowed ...string) string {
+	if t.Kind() == String {
+		s := unquoteString(t)
+		for _, a := range allowed {
+			if s == a {
+				return s
+			}
 		}
-		if !isWhitespace(data[start]) {
-			break
+	}
+	panic(unexpected(t) + ", wanted one of: " + strings.Join(allowed, ", "))
+}
+
+// EnumFold is like Enum, but matches the value case-insensitively (using
+// Unicode case folding), returning the first of allowed that matches, so that
+// "Active" comes back as "active".
+func (t Token) EnumFold(allowed ...string) string {
+	if t.Kind() == String {
+		s := unquoteString(t)
+		for _, a := range allowed {
+			if strings.EqualFold(s, a) {
+				return a
+			}
 		}
-		start++
 	}
+	panic(unexpected(t) + ", wanted one of: " + strings.Join(allowed, ", "))
+}
 
-	return kindByByte[data[start]], data[start:]
+// Offset returns the byte offset of this token within src, the input it has
+// been read from. The token must be a sub-slice of src, as all tokens returned
+// by tinyjson are (and not a copy); panics otherwise, including for EOF.
+func (t Token) Offset(src []byte) int {
+	start, _ := t.Range(src)
+	return start
 }
 
-func nextToken(data []byte) (token Token, remainder []byte) {
-	start := 0
-	n := len(data)
-	for {
-		if start == n {
-			return nil, nil
-		}
-		if !isWhitespace(data[start]) {
-			break
+// Range returns the byte offsets of the start and the end of this token within
+// src, so that src[start:end] is the token. See Offset for the requirements.
+func (t Token) Range(src []byte) (start, end int) {
+	if len(t) > 0 && len(src) > 0 {
+		p := uintptr(unsafe.Pointer(unsafe.SliceData(t)))
+		base := uintptr(unsafe.Pointer(unsafe.SliceData(src)))
+		if p >= base && p+uintptr(len(t)) <= base+uintptr(len(src)) {
+			start = int(p - base)
+			return start, start + len(t)
 		}
-		start++
+	}
+	panic("tinyjson: token is not within src")
+}
+
+// unexpected returns the panic message for a token of the wrong kind.
+func unexpected(t Token) string {
+	if t == nil {
+		return "unexpected end of JSON"
+	}
+	return "unexpected JSON: " + t.Raw()
+}
+
+// Hash returns the 64-bit FNV-1a hash of the raw JSON bytes of this token.
+// Tokens that differ only in escaping, like "a" and "\u0061", have different
+// hashes; hash Str() instead to compare string values. To use tokens as map
+// keys, use Raw(), which is comparable and doesn't copy.
+func (t Token) Hash() uint64 {
+	h := uint64(14695981039346656037)
+	for _, c := range t {
+		h ^= uint64(c)
+		h *= 1099511628211
+	}
+	return h
+}
+
+// ScanToken skips leading whitespace and returns the next token of data and
+// the data after it, or nil, nil at the end of data. It is the scanner behind
+// Raw.Next, for building custom parsers; like Next, it panics on invalid tokens.
+func ScanToken(data []byte) (token Token, remainder []byte) {
+	return nextToken(data, false, "string")
+}
+
+// ScanString returns the string token at the start of data, including the
+// quotes, and the data after it. Panics unless data starts with a complete
+// string. Doesn't validate or unescape the contents.
+func ScanString(data []byte) (token Token, remainder []byte) {
+	if len(data) == 0 || data[0] != '"' {
+		panic("invalid JSON")
+	}
+	return scanString(data)
+}
+
+// ScanNumber returns the longest run of number characters (digits, signs,
+// decimal points and exponents) at the start of data, and the data after it.
+// Panics if data doesn't start with a number character; the token is not
+// validated further.
+func ScanNumber(data []byte) (token Token, remainder []byte) {
+	if len(data) == 0 || kindByByte[data[0]] != Number {
+		panic("invalid JSON")
+	}
+	return scanNumber(data)
+}
+
+func peekNextTokenKind(data []byte) (kind Kind, remainder []byte) {
+	start := skipWhitespace(data)
+	if start == len(data) {
+		return EOF, nil
+	}
+	return kindByByte[data[start]], data[start:]
+}
+
+// nextToken is ScanToken, also rejecting what RFC 8259 doesn't allow if strict
+// is set. what names string tokens in the errors, like "string" or "object key".
+func nextToken(data []byte, strict bool, what string) (token Token, remainder []byte) {
+	start := skipWhitespace(data)
+	if start == len(data) {
+		return nil, nil
 	}
 
 	c := data[start]
 	switch c {
 	case '"':
-		return scanString(data[start:])
+		token, remainder := scanString(data[start:])
+		if strict {
+			checkString(token, what)
+		}
+		return token, remainder
 	case 't':
-		return trueToken, data[start+4:]
+		return scanLiteral(data[start:], TrueToken)
 	case 'f':
-		return falseToken, data[start+5:]
+		return scanLiteral(data[start:], FalseToken)
 	case 'n':
-		return nullToken, data[start+4:]
+		return scanLiteral(data[start:], NullToken)
 	default:
 		k := kindByByte[c]
 		if k == Number {
-			return scanNumber(data[start:])
+			token, remainder := scanNumber(data[start:])
+			if strict {
+				checkNumber(token)
+			}
+			return token, remainder
 		} else if k != 0 {
 			return Token(data[start : start+1]), data[start+1:]
 		} else {
-			panic("invalid JSON")
+			panic(invalidByte(data[start:]))
+		}
+	}
+}
+
+// skipWhitespace returns the index of the first non-whitespace byte of data,
+// or len(data) if none.
+func skipWhitespace(data []byte) int {
+	n := len(data)
+	for i := 0; i < n; i++ {
+		if !isWhitespace(data[i]) {
+			return i
 		}
 	}
+	return n
+}
+
+const bom = "\xEF\xBB\xBF"
+
+func hasBOM(data []byte) bool {
+	return len(data) >= len(bom) && string(data[:len(bom)]) == bom
+}
+
+// invalidByte returns the panic message for data starting with a byte that
+// cannot start a token.
+func invalidByte(data []byte) string {
+	if hasBOM(data) {
+		return "invalid JSON: unexpected byte order mark"
+	}
+	return "invalid JSON"
+}
+
+// TrimBOM returns data without a leading UTF-8 byte order mark, which Windows
+// editors commonly save, for parsing a whole document with Raw:
+//
+//	raw := tinyjson.Raw(tinyjson.TrimBOM(data))
+//
+// Raw doesn't know where the document starts, so it rejects byte order marks
+// anywhere. Decoder, and functions like Parse and Visit that take a whole
+// document, skip a leading one themselves.
+func TrimBOM(data []byte) []byte {
+	if hasBOM(data) {
+		return data[len(bom):]
+	}
+	return data
 }
 
 func scanString(data []byte) (Token, []byte) {
@@ -220,10 +731,21 @@ func scanString(data []byte) (Token, []byte) {
 		case '"':
 			return Token(data[:i+1]), data[i+1:]
 		case '\\':
+			if i+1 == n {
+				panic("invalid JSON: unterminated escape")
+			}
 			i++
 		}
 	}
-	panic("invalid JSON")
+	panic("invalid JSON: unterminated string")
+}
+
+func scanLiteral(data []byte, literal Token) (Token, []byte) {
+	n := len(literal)
+	if len(data) < n || string(data[:n]) != string(literal) {
+		panic("invalid JSON")
+	}
+	return Token(data[:n]), data[n:] // not literal, so that tokens always point into the input
 }
 
 func scanNumber(data []byte) (Token, []byte) {
@@ -238,19 +760,102 @@ func scanNumber(data []byte) (Token, []byte) {
 	return Token(data), nil
 }
 
+// checkNumber panics unless t is a number formatted as RFC 8259 requires, that
+// is, without a leading plus, leading zeros or a bare decimal point, all of
+// which Raw accepts by default. The panic message names the problem found.
+func checkNumber(t Token) {
+	if problem := numberProblem(t); problem != "" {
+		panic("invalid JSON: " + problem + " in number " + t.Raw())
+	}
+}
+
+func numberProblem(t Token) string {
+	i, n := 0, len(t)
+	switch t[0] {
+	case '+':
+		return "leading plus sign"
+	case '-':
+		i++
+	}
+	if i < n && t[i] == '0' {
+		i++
+		if i < n && t[i] >= '0' && t[i] <= '9' {
+			return "leading zero"
+		}
+	} else if j := skipDigits(t, i); j > i {
+		i = j
+	} else if i < n && t[i] == '.' {
+		return "decimal point without preceding digits"
+	} else {
+		return "missing digits"
+	}
+	if i < n && t[i] == '.' {
+		j := skipDigits(t, i+1)
+		if j == i+1 {
+			if j < n && t[j] == '.' {
+				return "multiple decimal points"
+			}
+			return "decimal point without following digits"
+		}
+		i = j
+	}
+	exponent := false
+	if i < n && (t[i] == 'e' || t[i] == 'E') {
+		exponent = true
+		i++
+		if i < n && (t[i] == '+' || t[i] == '-') {
+			i++
+		}
+		j := skipDigits(t, i)
+		if j == i {
+			return "exponent without digits"
+		}
+		i = j
+	}
+	if i == n {
+		return ""
+	}
+	switch t[i] {
+	case '.':
+		if exponent {
+			return "decimal point in exponent"
+		}
+		return "multiple decimal points"
+	case 'e', 'E':
+		return "multiple exponents"
+	default:
+		return "misplaced sign"
+	}
+}
+
+func skipDigits(t Token, i int) int {
+	for i < len(t) && t[i] >= '0' && t[i] <= '9' {
+		i++
+	}
+	return i
+}
+
 func unquoteString(s []byte) string {
-	n := len(s)
-	s = s[1 : n-1]
-	n -= 2
+	s = s[1 : len(s)-1]
+	if len(s) == 0 {
+		return ""
+	}
 	if !hasEscape(s) {
-		return unsafe.String(&s[0], len(s))
+		return aliased(unsafe.String(&s[0], len(s)))
 	}
-	var buf strings.Builder
-	buf.Grow(len(s))
+	buf := appendUnescaped(make([]byte, 0, len(s)), s)
+	return unsafe.String(&buf[0], len(buf)) // escapes never decode to nothing
+}
+
+// appendUnescaped appends the string contents s (without quotes) to dst with
+// escape sequences decoded. Unknown escapes like \x decode to the escaped
+// character; use checkString first to reject them.
+func appendUnescaped(dst, s []byte) []byte {
+	n := len(s)
 	for i := 0; i < n; i++ {
 		c := s[i]
 		if c != '\\' {
-			buf.WriteByte(c)
+			dst = append(dst, c)
 		} else {
 			i++
 			if i == n {
@@ -259,31 +864,113 @@ func unquoteString(s []byte) string {
 			c = s[i]
 			switch c {
 			case 'b':
-				buf.WriteByte('\b')
+				dst = append(dst, '\b')
 			case 'f':
-				buf.WriteByte('\f')
+				dst = append(dst, '\f')
 			case 'n':
-				buf.WriteByte('\n')
+				dst = append(dst, '\n')
 			case 'r':
-				buf.WriteByte('\r')
+				dst = append(dst, '\r')
 			case 't':
-				buf.WriteByte('\t')
+				dst = append(dst, '\t')
 			case 'u':
-				if i+4 >= n {
+				if i+4 >= n { // the 4 hex digits are s[i+1:i+5]
 					panic("invalid JSON")
 				}
 				u, err := strconv.ParseUint(unsafe.String(&s[i+1], 4), 16, 32)
 				if err != nil {
 					panic("invalid JSON")
 				}
-				buf.WriteRune(rune(u))
+				dst = utf8.AppendRune(dst, rune(u))
 				i += 4
 			default:
-				buf.WriteByte(c)
+				dst = append(dst, c)
+			}
+		}
+	}
+	return dst
+}
+
+// checkString panics unless the string token t is valid UTF-8 without control
+// characters, and every escape sequence in it is one that RFC 8259 defines,
+// rejecting ones like \x that Raw accepts by default. what names the token in
+// the error, like "string" or "object key".
+func checkString(t Token, what string) {
+	s := t[1 : len(t)-1]
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 {
+			panic("invalid JSON: control character in " + what + " " + strconv.Quote(t.Raw()))
+		}
+		if s[i] != '\\' {
+			continue
+		}
+		n := escapeLen(s[i:])
+		if n == 0 {
+			panic("invalid JSON: invalid escape sequence " + string(s[i:i+2]) + " in " + what + " " + t.Raw())
+		}
+		i += n - 1
+	}
+	checkUTF8(t, what)
+}
+
+// checkUTF8 panics unless the string token t is valid UTF-8, telling apart
+// multibyte sequences cut short, whether by the closing quote, a backslash or
+// any other byte, from other invalid bytes.
+func checkUTF8(t Token, what string) {
+	s := t[1 : len(t)-1]
+	if utf8.Valid(s) {
+		return
+	}
+	for {
+		r, n := utf8.DecodeRune(s)
+		if r == utf8.RuneError && n == 1 {
+			break
+		}
+		s = s[n:]
+	}
+	need := 0
+	switch c := s[0]; {
+	case c >= 0xC2 && c <= 0xDF:
+		need = 2
+	case c >= 0xE0 && c <= 0xEF:
+		need = 3
+	case c >= 0xF0 && c <= 0xF4:
+		need = 4
+	}
+	n := 1
+	for n < need && n < len(s) && s[n]&0xC0 == 0x80 {
+		n++
+	}
+	if n < need {
+		panic("invalid JSON: truncated UTF-8 sequence in " + what + " " + strconv.Quote(t.Raw()))
+	}
+	panic("invalid JSON: invalid UTF-8 in " + what + " " + strconv.Quote(t.Raw()))
+}
+
+// escapeLen returns the length of the valid escape sequence at the start of s,
+// which must start with a backslash followed by at least one byte, or 0 if
+// the sequence is invalid.
+func escapeLen(s []byte) int {
+	switch s[1] {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+		return 2
+	case 'u':
+		if len(s) < 6 {
+			return 0
+		}
+		for _, c := range s[2:6] {
+			if !isHexDigit(c) {
+				return 0
 			}
 		}
+		return 6
+	default:
+		return 0
 	}
-	return buf.String()
+}
+
+func isHexDigit(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
 }
 
 func hasEscape(s []byte) bool {
@@ -302,59 +989,247 @@ func isWhitespace(b byte) bool {
 // Raw is a []byte encoding of an unparsed portion of JSON document.
 type Raw []byte
 
+// Fork returns a copy of raw that advances independently of it, for probing
+// ahead speculatively and then either discarding the fork or continuing from
+// it with raw = fork. Plain assignment does the same, since Raw is a slice;
+// Fork spells out the intent, unlike copying a *Raw, which shares the
+// position. Forks share the input bytes, which tinyjson never modifies.
+func (raw Raw) Fork() Raw {
+	return raw
+}
+
 // Next returns the next token in the JSON data.
 func (raw *Raw) Next() Token {
-	token, remainder := nextToken(*raw)
-	*raw = Raw(remainder)
-	return token
+	return raw.next(nil)
 }
 
-// Peek returns what Next().Kind() would return without advancing past the next
-// token. (Peek does advance past leading whitespace to run in amortized O(1),
-// assuming all tokens will be eventually scanned or skipped over.)
-func (raw *Raw) Peek() Kind {
-	kind, remainder := peekNextTokenKind(*raw)
+func (raw *Raw) next(d *Decoder) Token {
+	return raw.nextAs(d, "string")
+}
+
+// nextKey is next for where an object key may come, naming string tokens
+// "object key" in errors, so that a malformed key is easy to tell apart.
+func (raw *Raw) nextKey(d *Decoder) Token {
+	return raw.nextAs(d, "object key")
+}
+
+func (raw *Raw) nextAs(d *Decoder, what string) Token {
+	raw.skipBOM(d)
+	token, remainder := nextToken(*raw, d.strict(), what)
+	if d != nil && d.ValidUTF8 && token.Kind() == String {
+		checkUTF8(token, what)
+	}
+	d.advance(len(*raw) - len(remainder))
 	*raw = Raw(remainder)
-	return kind
+	if d != nil && d.Trace != nil {
+		d.trace(token, len(remainder))
+	}
+	if d.strict() {
+		d.first = token.Kind() == StartObject || token.Kind() == StartArray
+	}
+	return token
 }
 
-// StartObject ensures the next token is an open curly brace and returns
-// the first object key. Returns nil for an empty object.
+// NextComplete is like Next, but returns nil and false without advancing if
+// the data ends before the next token is complete, so that the caller can
+// append more data (e.g. the next chunk read from a socket) and retry. This is
+// the case when only whitespace remains, and when the data ends:
 //
-// Idiomatic usage:
+//   - inside a string, including right after a backslash;
+//   - after a prefix of true, false or null, like tr;
+//   - inside a number, since more digits may follow in the next chunk;
+//   - after a prefix of a byte order mark.
 //
-//	for key := raw.StartObject(); key != nil; key = raw.ContinueObject() {
+// Once the input is known to be over, call Next to read the final token.
+// Invalid data still panics like with Next.
+func (raw *Raw) NextComplete() (Token, bool) {
+	return raw.nextComplete(nil)
+}
+
+func (raw *Raw) nextComplete(d *Decoder) (Token, bool) {
+	raw.skipBOM(d)
+	if isIncomplete((*raw)[skipWhitespace(*raw):]) {
+		return nil, false
+	}
+	return raw.next(d), true
+}
+
+func isIncomplete(data []byte) bool {
+	if len(data) == 0 {
+		return true
+	}
+	switch data[0] {
+	case '"':
+		for i := 1; i < len(data); i++ {
+			switch data[i] {
+			case '"':
+				return false
+			case '\\':
+				i++
+			}
+		}
+		return true
+	case 't':
+		return isProperPrefix(data, TrueToken.Raw())
+	case 'f':
+		return isProperPrefix(data, FalseToken.Raw())
+	case 'n':
+		return isProperPrefix(data, NullToken.Raw())
+	case bom[0]:
+		return isProperPrefix(data, bom)
+	default:
+		if kindByByte[data[0]] == Number {
+			_, remainder := scanNumber(data)
+			return len(remainder) == 0
+		}
+		return false
+	}
+}
+
+func isProperPrefix(data []byte, s string) bool {
+	return len(data) < len(s) && string(data) == s[:len(data)]
+}
+
+// Tokens returns an iterator over the remaining tokens and their kinds, which
+// advances raw past each token it yields. Its type is iter.Seq2[Kind, Token],
+// spelled out to keep this package buildable by older Go versions:
+//
+//	for kind, token := range raw.Tokens() { ... }
+func (raw *Raw) Tokens() func(yield func(Kind, Token) bool) {
+	return raw.tokens(nil)
+}
+
+func (raw *Raw) tokens(d *Decoder) func(yield func(Kind, Token) bool) {
+	return func(yield func(Kind, Token) bool) {
+		for {
+			t := raw.next(d)
+			if t == nil || !yield(t.Kind(), t) {
+				return
+			}
+		}
+	}
+}
+
+// Peek returns what Next().Kind() would return without advancing past the next
+// token. (Peek does advance past leading whitespace to run in amortized O(1),
+// assuming all tokens will be eventually scanned or skipped over.)
+//
+// Peek only ever drops whitespace, so the remaining tokens stay the same: Next
+// returns the token whose kind Peek reported, or nil after EOF. Like Next, it
+// panics on bytes that cannot start a token; it doesn't check the rest of the
+// token, though, so Next may still panic on, say, tru. Use PeekKind to leave
+// raw intact.
+func (raw *Raw) Peek() Kind {
+	return raw.peek(nil)
+}
+
+// PeekKind is like Peek, but doesn't advance at all, at the cost of skipping
+// the same whitespace again on the next call.
+func (raw Raw) PeekKind() Kind {
+	return raw.peek(nil)
+}
+
+func (raw *Raw) peek(d *Decoder) Kind {
+	raw.skipBOM(d)
+	kind, remainder := peekNextTokenKind(*raw)
+	d.advance(len(*raw) - len(remainder))
+	*raw = Raw(remainder)
+	if kind == EOF && remainder != nil {
+		panic(invalidByte(remainder))
+	}
+	return kind
+}
+
+// StartObject ensures the next token is an open curly brace and returns
+// the first object key. Returns nil for an empty object.
+//
+// Idiomatic usage:
+//
+//	for key := raw.StartObject(); key != nil; key = raw.ContinueObject() {
 //		switch key.Str() { ... }
 //	}
 func (raw *Raw) StartObject() Token {
-	if t := raw.Next(); t.Kind() != StartObject {
-		panic("unexpected JSON: " + t.Raw())
+	return raw.startObject(nil)
+}
+
+func (raw *Raw) startObject(d *Decoder) Token {
+	if t := raw.next(d); t.Kind() != StartObject {
+		panic(unexpected(t))
 	}
-	return raw.ContinueObject()
+	return raw.continueObject(d)
 }
 
 // ContinueObject returns the next object key, skipping over a comma if any.
 // Returns nil if no more keys are present.
 func (raw *Raw) ContinueObject() Token {
+	return raw.continueObject(nil)
+}
+
+func (raw *Raw) continueObject(d *Decoder) Token {
+	if d.strict() {
+		return raw.continueObjectStrict(d)
+	}
 again:
-	t := raw.Next()
+	t := raw.nextKey(d)
 	switch t.Kind() {
 	case Comma:
 		goto again
 	case String:
-		colon := raw.Next()
+		if d != nil && d.InferColons && raw.peek(d) != Colon {
+			return t
+		}
+		colon := raw.next(d)
 		if colon.Kind() != Colon {
-			panic("invalid JSON")
+			panic(invalidOrEnd(colon))
 		}
 		return t
 	case EndObject:
 		return nil
 	default:
 		// log.Printf("t = >>>%s<<<, raw = >>>%s<<<", t, *raw)
-		panic("invalid JSON")
+		panic(invalidOrEnd(t))
 	}
 }
 
+// invalidOrEnd returns the panic message for token t found where it doesn't
+// belong, telling truncated input, like [1, or {"a", apart.
+func invalidOrEnd(t Token) string {
+	if t == nil {
+		return "unexpected end of JSON"
+	}
+	return "invalid JSON"
+}
+
+// continueObjectStrict is continueObject requiring exactly one comma between
+// members, and none before the first one or after the last one.
+func (raw *Raw) continueObjectStrict(d *Decoder) Token {
+	first := d.first
+	t := raw.nextKey(d)
+	switch t.Kind() {
+	case EndObject:
+		return nil
+	case Comma:
+		if first {
+			panic("invalid JSON: unexpected comma")
+		}
+		if t = raw.nextKey(d); t == nil {
+			panic("unexpected end of JSON")
+		} else if t.Kind() != String {
+			panic("invalid JSON: expected key after comma")
+		}
+	case String:
+		if !first {
+			panic("invalid JSON: missing comma")
+		}
+	default:
+		panic(invalidOrEnd(t))
+	}
+	if colon := raw.next(d); colon.Kind() != Colon {
+		panic(invalidOrEnd(colon))
+	}
+	return t
+}
+
 // StartArray ensures the next token is an open square bracket. Follow up with
 // a call to ContinueArray to iterate over the array elements:
 //
@@ -362,22 +1237,63 @@ again:
 //		// process the next value here via .Next(), .Skip(), .Str(), .Int(), etc.
 //	}
 func (raw *Raw) StartArray() {
-	if t := raw.Next(); t.Kind() != StartArray {
-		panic("unexpected JSON: " + t.Raw())
+	raw.startArray(nil)
+}
+
+func (raw *Raw) startArray(d *Decoder) {
+	if t := raw.next(d); t.Kind() != StartArray {
+		panic(unexpected(t))
 	}
 }
+
 func (raw *Raw) ContinueArray() bool {
+	return raw.continueArray(nil)
+}
+
+func (raw *Raw) continueArray(d *Decoder) bool {
+	if d.strict() {
+		return raw.continueArrayStrict(d)
+	}
 again:
-	switch raw.Peek() {
+	switch raw.peek(d) {
 	case Comma:
-		raw.Next()
+		raw.next(d)
 		goto again
 	case EndArray:
-		raw.Next()
+		raw.next(d)
+		return false
+	case EOF:
+		panic("unexpected end of JSON")
+	default:
+		return true
+	}
+}
+
+// continueArrayStrict is continueArray requiring exactly one comma between
+// elements, and none before the first one or after the last one.
+func (raw *Raw) continueArrayStrict(d *Decoder) bool {
+	first := d.first
+	switch raw.peek(d) {
+	case Comma:
+		if first {
+			panic("invalid JSON: unexpected comma")
+		}
+		raw.next(d)
+		if k := raw.peek(d); k == Comma || k == EndArray {
+			panic("invalid JSON: expected value after comma")
+		}
+		return true
+	case EndArray:
+		raw.next(d)
 		return false
 	case EOF:
+		panic("unexpected end of JSON")
+	case EndObject, Colon:
 		panic("invalid JSON")
 	default:
+		if !first {
+			panic("invalid JSON: missing comma")
+		}
 		return true
 	}
 }
@@ -385,13 +1301,44 @@ again:
 // Null skips 'null' token and returns true if the next token is null,
 // returns false without advancing the parser otherwise.
 func (raw *Raw) Null() bool {
-	if raw.Peek() == Null {
-		raw.Next()
+	return raw.null(nil)
+}
+
+func (raw *Raw) null(d *Decoder) bool {
+	if raw.peek(d) == Null {
+		raw.next(d)
 		return true
 	}
 	return false
 }
 
+// NullOrEOF is like Null, but also returns true at EOF, for optional values
+// that may simply be absent at the end of the input.
+func (raw *Raw) NullOrEOF() bool {
+	return raw.nullOrEOF(nil)
+}
+
+func (raw *Raw) nullOrEOF(d *Decoder) bool {
+	return raw.null(d) || raw.peek(d) == EOF
+}
+
+// NullString returns "" and false if the next token is null, and the next
+// string and true otherwise, for nullable values like sql.NullString holds.
+func (raw *Raw) NullString() (string, bool) {
+	return nullable(raw, nil, Token.Str)
+}
+
+func (raw *Raw) NullInt64() (int64, bool)     { return nullable(raw, nil, Token.Int64) } // NullInt64 is like NullString, but for Int64
+func (raw *Raw) NullFloat64() (float64, bool) { return nullable(raw, nil, Token.Float) } // NullFloat64 is like NullString, but for Float
+func (raw *Raw) NullBool() (bool, bool)       { return nullable(raw, nil, Token.Bool) }  // NullBool is like NullString, but for Bool
+
+func nullable[T any](raw *Raw, d *Decoder, get func(Token) T) (v T, valid bool) {
+	if raw.null(d) {
+		return v, false
+	}
+	return get(raw.next(d)), true
+}
+
 func (raw *Raw) Str() string    { return raw.Next().Str() }    // Str returns .Next().Str()
 func (raw *Raw) Int() int       { return raw.Next().Int() }    // Int returns .Next().Int()
 func (raw *Raw) Int64() int64   { return raw.Next().Int64() }  // Int64 returns .Next().Int64()
@@ -399,53 +1346,712 @@ func (raw *Raw) Uint64() uint64 { return raw.Next().Uint64() } // Uint64 returns
 func (raw *Raw) Float() float64 { return raw.Next().Float() }  // Float returns .Next().Float()
 func (raw *Raw) Bool() bool     { return raw.Next().Bool() }   // Bool returns .Next().Bool()
 
+func (raw *Raw) IntExact() (int64, bool) { return raw.Next().IntExact() } // IntExact returns .Next().IntExact()
+
+func (raw *Raw) Int8() int8     { return raw.Next().Int8() }   // Int8 returns .Next().Int8()
+func (raw *Raw) Int16() int16   { return raw.Next().Int16() }  // Int16 returns .Next().Int16()
+func (raw *Raw) Int32() int32   { return raw.Next().Int32() }  // Int32 returns .Next().Int32()
+func (raw *Raw) Uint8() uint8   { return raw.Next().Uint8() }  // Uint8 returns .Next().Uint8()
+func (raw *Raw) Uint16() uint16 { return raw.Next().Uint16() } // Uint16 returns .Next().Uint16()
+func (raw *Raw) Uint32() uint32 { return raw.Next().Uint32() } // Uint32 returns .Next().Uint32()
+
+func (raw *Raw) FloatLenient() float64   { return raw.Next().FloatLenient() }    // FloatLenient returns .Next().FloatLenient()
+func (raw *Raw) FloatFast() float64      { return raw.Next().FloatFast() }       // FloatFast returns .Next().FloatFast()
+func (raw *Raw) NumberCanonical() string { return raw.Next().NumberCanonical() } // NumberCanonical returns .Next().NumberCanonical()
+
+func (raw *Raw) SafeStr() string                   { return raw.Next().SafeStr() }            // SafeStr returns .Next().SafeStr()
+func (raw *Raw) Enum(allowed ...string) string     { return raw.Next().Enum(allowed...) }     // Enum returns .Next().Enum(allowed...)
+func (raw *Raw) StrTrimmed() string                { return raw.Next().StrTrimmed() }         // StrTrimmed returns .Next().StrTrimmed()
+func (raw *Raw) EnumFold(allowed ...string) string { return raw.Next().EnumFold(allowed...) } // EnumFold returns .Next().EnumFold(allowed...)
+
 // Value returns the next JSON value; arrays are returned as []any, objects as map[string]any.
+// Like all methods that need a value, panics with "unexpected end of JSON" if
+// there is none; Next and Peek return EOF instead. Values nested deeper than
+// ValueRecursionDepth are decoded like ValueIter, so deep input cannot overflow
+// the stack.
 func (raw *Raw) Value() any {
-	t := raw.Next()
+	return raw.value(nil)
+}
+
+// ValueRecursionDepth is the nesting depth at which Value and the functions
+// built on it switch from recursion, which is faster, to the explicit stack
+// of ValueIter. Set it to 0 to always use the stack.
+var ValueRecursionDepth = 100
+
+func (raw *Raw) value(d *Decoder) any {
+	return raw.valueRec(d, 0)
+}
+
+func (raw *Raw) valueRec(d *Decoder, depth int) any {
+	if depth >= ValueRecursionDepth {
+		return raw.valueIter(d)
+	}
+	t := raw.next(d)
 	switch t.Kind() {
 	case EOF:
-		return nil
+		panic("unexpected end of JSON")
 	case StartObject:
+		d.enter()
+		defer d.leave()
+		result := d.pool().newMap()
+		for key := raw.continueObject(d); key != nil; key = raw.continueObject(d) {
+			if k := d.key(key); d.member(result, k, key) {
+				result[k] = raw.valueRec(d, depth+1)
+			} else {
+				raw.skip(d)
+			}
+		}
+		return result
+	case StartArray:
+		d.enter()
+		defer d.leave()
+		var result []any
+		for raw.continueArray(d) {
+			if result == nil {
+				result = d.pool().newSlice()
+			}
+			result = append(result, raw.valueRec(d, depth+1))
+		}
+		return result
+	case String, Number, True, False, Null:
+		return d.scalar(t)
+	default:
+		panic("invalid JSON")
+	}
+}
+
+// CaptureExtra decodes the next value with Value and stores it in dst under
+// key, for preserving unknown object members instead of rejecting them:
+//
+//	for key := raw.StartObject(); key != nil; key = raw.ContinueObject() {
+//		switch key.Str() {
+//		case "name":
+//			foo.Name = raw.Str()
+//		default:
+//			if foo.Extra == nil {
+//				foo.Extra = make(map[string]any)
+//			}
+//			raw.CaptureExtra(foo.Extra, key)
+//		}
+//	}
+func (raw *Raw) CaptureExtra(dst map[string]any, key Token) {
+	raw.captureExtra(nil, dst, key)
+}
+
+func (raw *Raw) captureExtra(d *Decoder, dst map[string]any, key Token) {
+	if k := d.key(key); d.member(dst, k, key) {
+		dst[k] = raw.value(d)
+	} else {
+		raw.skip(d)
+	}
+}
+
+// ValueCanonical is like Value, but panics on duplicate object keys instead
+// of keeping the last value, so that the result represents the input
+// unambiguously. Together with AppendValue, which sorts object keys, it gives
+// a reproducible form of a document suitable for hashing and signing.
+func (raw *Raw) ValueCanonical() any {
+	return raw.valueCanonical(nil)
+}
+
+func (raw *Raw) valueCanonical(d *Decoder) any {
+	t := raw.next(d)
+	switch t.Kind() {
+	case EOF:
+		panic("unexpected end of JSON")
+	case StartObject:
+		d.enter()
+		defer d.leave()
 		result := make(map[string]any)
-		for key := raw.ContinueObject(); key != nil; key = raw.ContinueObject() {
-			result[key.Str()] = raw.Value()
+		for key := raw.continueObject(d); key != nil; key = raw.continueObject(d) {
+			k := d.key(key)
+			if _, dup := result[k]; dup {
+				panic("invalid JSON: duplicate key " + key.Raw())
+			}
+			result[k] = raw.valueCanonical(d)
+		}
+		return result
+	case StartArray:
+		d.enter()
+		defer d.leave()
+		var result []any
+		for raw.continueArray(d) {
+			result = append(result, raw.valueCanonical(d))
+		}
+		return result
+	case String, Number, True, False, Null:
+		return d.scalar(t)
+	default:
+		panic("invalid JSON")
+	}
+}
+
+// KV is an object member returned by ValueOrdered.
+type KV struct {
+	Key   string
+	Value any
+}
+
+// ValueOrdered is like Value, but returns objects as []KV, preserving the order
+// of keys (and any duplicate keys) found in the input.
+func (raw *Raw) ValueOrdered() any {
+	return raw.valueOrdered(nil)
+}
+
+func (raw *Raw) valueOrdered(d *Decoder) any {
+	t := raw.next(d)
+	switch t.Kind() {
+	case EOF:
+		panic("unexpected end of JSON")
+	case StartObject:
+		d.enter()
+		defer d.leave()
+		var result []KV
+		for key := raw.continueObject(d); key != nil; key = raw.continueObject(d) {
+			result = append(result, KV{d.key(key), raw.valueOrdered(d)})
 		}
 		return result
 	case StartArray:
+		d.enter()
+		defer d.leave()
 		var result []any
-		for raw.ContinueArray() {
-			result = append(result, raw.Value())
+		for raw.continueArray(d) {
+			result = append(result, raw.valueOrdered(d))
 		}
 		return result
 	case String, Number, True, False, Null:
-		return t.Scalar()
+		return d.scalar(t)
 	default:
 		panic("invalid JSON")
 	}
 }
 
+// ValueAs is like Value, but panics unless the next value is of the given
+// kind, asserting the type of a field in schema-driven decoding: String
+// returns a string, Number a float64, True or False a bool (both accept
+// either), Null nil, StartObject a map[string]any and StartArray an []any.
+func (raw *Raw) ValueAs(kind Kind) any {
+	return raw.valueAs(nil, kind)
+}
+
+func (raw *Raw) valueAs(d *Decoder, kind Kind) any {
+	if k := raw.peek(d); k != kind && !(isBool(k) && isBool(kind)) {
+		panic(unexpected(raw.next(d)))
+	}
+	return raw.value(d)
+}
+
+func isBool(k Kind) bool {
+	return k == True || k == False
+}
+
+// ValueTyped is like Value, but returns integers that fit into int64, like
+// 42 and -9223372036854775808, as int64 instead of float64. Other numbers,
+// including integers beyond the int64 range and ones written with a fraction
+// or exponent, like 9223372036854775808, 1.0 and 1e2, are still returned as
+// float64, so that untrusted input cannot make it panic with an overflow;
+// ones beyond the range of float64, like 1e400, become ±Inf.
+func (raw *Raw) ValueTyped() any {
+	d := Decoder{Raw: *raw, NumberParser: IntOrFloat, started: true}
+	defer func() { *raw = d.Raw }()
+	return d.Raw.value(&d)
+}
+
+// IntOrFloat parses a JSON number into an int64 if it is an integer within
+// range, or a float64 otherwise. Numbers beyond the range of float64, like
+// 1e400, become ±Inf, which AppendValue refuses to encode. It is the
+// NumberParser behind ValueTyped.
+func IntOrFloat(raw []byte) (any, error) {
+	s := Token(raw).Raw()
+	if v, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return v, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil && err.(*strconv.NumError).Err == strconv.ErrRange {
+		return v, nil // ±Inf
+	}
+	return v, err
+}
+
+// ValueIter returns the same result as Value, but walks nested objects and
+// arrays with an explicit stack instead of recursion, so adversarially deep
+// input cannot overflow the goroutine stack, which is small and fixed under
+// tinygo. On shallow data it is about as fast as Value.
+func (raw *Raw) ValueIter() any {
+	return raw.valueIter(nil)
+}
+
+type valueFrame struct {
+	obj map[string]any // nil for arrays
+	arr []any
+	key string
+	dup bool // whether to drop the value of key, see Decoder.member
+}
+
+func (raw *Raw) valueIter(d *Decoder) any {
+	var buf [16]valueFrame
+	stack := buf[:0]
+	for {
+		var v any
+		t := raw.next(d)
+		switch t.Kind() {
+		case EOF:
+			panic("unexpected end of JSON")
+		case StartObject:
+			d.enter()
+			if key := raw.continueObject(d); key != nil {
+				stack = append(stack, valueFrame{obj: d.pool().newMap(), key: d.key(key)})
+				continue
+			}
+			d.leave()
+			v = d.pool().newMap()
+		case StartArray:
+			d.enter()
+			if raw.continueArray(d) {
+				stack = append(stack, valueFrame{})
+				continue
+			}
+			d.leave()
+			v = []any(nil)
+		case String, Number, True, False, Null:
+			v = d.scalar(t)
+		default:
+			panic("invalid JSON")
+		}
+
+		// store v into its parent, completing as many parents as possible
+		for {
+			if len(stack) == 0 {
+				return v
+			}
+			top := &stack[len(stack)-1]
+			if top.obj != nil {
+				if !top.dup {
+					top.obj[top.key] = v
+				}
+				if key := raw.continueObject(d); key != nil {
+					top.key = d.key(key)
+					top.dup = !d.member(top.obj, top.key, key)
+					break
+				}
+				v = top.obj
+			} else {
+				if top.arr == nil {
+					top.arr = d.pool().newSlice()
+				}
+				top.arr = append(top.arr, v)
+				if raw.continueArray(d) {
+					break
+				}
+				v = top.arr
+			}
+			*top = valueFrame{}
+			stack = stack[:len(stack)-1]
+			d.leave()
+		}
+	}
+}
+
 // Skip advances past the next JSON value (including skipping over objects and arrays).
 func (raw *Raw) Skip() {
-	t := raw.Next()
+	raw.skip(nil)
+}
+
+func (raw *Raw) skip(d *Decoder) {
+	t := raw.next(d)
 	switch t.Kind() {
 	case StartObject:
-		for key := raw.ContinueObject(); key != nil; key = raw.ContinueObject() {
-			raw.Skip()
+		d.enter()
+		defer d.leave()
+		for key := raw.continueObject(d); key != nil; key = raw.continueObject(d) {
+			raw.skip(d)
 		}
 	case StartArray:
-		for raw.ContinueArray() {
-			raw.Skip()
+		d.enter()
+		defer d.leave()
+		for raw.continueArray(d) {
+			raw.skip(d)
 		}
 	case String, Number, True, False, Null:
 		break
+	case EOF:
+		panic("unexpected end of JSON")
 	default:
 		panic("invalid JSON")
 	}
 }
 
+// SkipN is like Skip, but returns the number of bytes taken by the skipped
+// value, not counting any whitespace before it.
+func (raw *Raw) SkipN() int {
+	return raw.skipN(nil)
+}
+
+func (raw *Raw) skipN(d *Decoder) int {
+	raw.peek(d)
+	n := len(*raw)
+	raw.skip(d)
+	return n - len(*raw)
+}
+
+// SkipContainer advances past the end of the innermost object or array being
+// decoded, consuming the rest of its entries or elements and the closing
+// brace or bracket, to leave it early once everything needed has been read:
+//
+//	for key := raw.StartObject(); key != nil; key = raw.ContinueObject() {
+//		if key.KeyIs("id") {
+//			id = raw.Str()
+//			raw.SkipContainer()
+//			break
+//		}
+//		raw.Skip()
+//	}
+func (raw *Raw) SkipContainer() {
+	raw.skipContainer(nil)
+}
+
+func (raw *Raw) skipContainer(d *Decoder) {
+	for depth := 1; depth > 0; {
+		switch raw.next(d).Kind() {
+		case StartObject, StartArray:
+			depth++
+		case EndObject, EndArray:
+			depth--
+		case EOF:
+			panic("unexpected end of JSON")
+		}
+	}
+}
+
+// ArrayIndex starts decoding an array and advances to its element i, returning
+// true, so that the element is decoded next, or false, with the whole array
+// consumed, if it has no such element. Use SkipContainer to consume the
+// elements after i, e.g. for a GeoJSON position:
+//
+//	if raw.ArrayIndex(1) {
+//		lat = raw.Float()
+//		raw.SkipContainer()
+//	}
+func (raw *Raw) ArrayIndex(i int) bool {
+	return raw.arrayElement(nil, i)
+}
+
+func (raw *Raw) arrayElement(d *Decoder, i int) bool {
+	for raw.startArray(d); raw.continueArray(d); i-- {
+		if i == 0 {
+			return true
+		}
+		raw.skip(d)
+	}
+	return false
+}
+
+// AtEOF reports whether only whitespace remains, without consuming anything.
+// Unlike EnsureEOF, it doesn't treat more data as an error, e.g. when
+// another document may follow.
+func (raw Raw) AtEOF() bool {
+	return skipWhitespace(raw) == len(raw)
+}
+
+// More reports whether another value follows, i.e. whether anything but
+// whitespace remains, without consuming anything. Like the method of
+// encoding/json.Decoder, it is handy for reading streams of values:
+//
+//	for raw.More() {
+//		v := raw.Value()
+//	}
+func (raw Raw) More() bool {
+	return !raw.AtEOF()
+}
+
+// IsEmptyObject reports whether the next value is {}, without consuming
+// anything, e.g. to avoid allocating a container for an empty collection.
+func (raw Raw) IsEmptyObject() bool {
+	return raw.isEmpty(StartObject, EndObject)
+}
+
+// IsEmptyArray reports whether the next value is [], without consuming anything.
+func (raw Raw) IsEmptyArray() bool {
+	return raw.isEmpty(StartArray, EndArray)
+}
+
+func (raw Raw) isEmpty(start, end Kind) bool {
+	kind, remainder := peekNextTokenKind(raw)
+	if kind != start {
+		return false
+	}
+	kind, _ = peekNextTokenKind(remainder[1:])
+	return kind == end
+}
+
 // EnsureEOF panics if more JSON data is found.
 func (raw *Raw) EnsureEOF() {
-	if raw.Peek() != EOF {
+	raw.ensureEOF(nil)
+}
+
+func (raw *Raw) ensureEOF(d *Decoder) {
+	if raw.peek(d) != EOF {
+		panic("invalid JSON")
+	}
+}
+
+// TokenCount returns the number of tokens in data, including structural ones
+// like braces, colons and commas. It panics on invalid tokens, but doesn't
+// verify that the tokens form valid JSON.
+func TokenCount(data []byte) int {
+	raw := Raw(TrimBOM(data))
+	n := 0
+	for raw.Next() != nil {
+		n++
+	}
+	return n
+}
+
+// NestingDepth returns how deeply the next value nests objects and arrays, 0 for
+// a scalar, 1 for {"a": 1} or [] and 2 for [[1], {}], without consuming it,
+// for characterizing input together with TokenCount, e.g. to pick a MaxDepth.
+// Panics on invalid JSON like Skip.
+func (raw Raw) NestingDepth() int {
+	return raw.nestingDepth(nil)
+}
+
+// nestingDepth is like skip, but returns the depth of the skipped value.
+func (raw *Raw) nestingDepth(d *Decoder) int {
+	deepest := 0
+	switch raw.next(d).Kind() {
+	case StartObject:
+		d.enter()
+		defer d.leave()
+		for key := raw.continueObject(d); key != nil; key = raw.continueObject(d) {
+			if n := raw.nestingDepth(d); n > deepest {
+				deepest = n
+			}
+		}
+	case StartArray:
+		d.enter()
+		defer d.leave()
+		for raw.continueArray(d) {
+			if n := raw.nestingDepth(d); n > deepest {
+				deepest = n
+			}
+		}
+	case String, Number, True, False, Null:
+		return 0
+	case EOF:
+		panic("unexpected end of JSON")
+	default:
 		panic("invalid JSON")
 	}
+	return deepest + 1
+}
+
+// SyntaxError describes invalid JSON reported by Parse and Lint.
+type SyntaxError struct {
+	Msg    string // the message tinyjson would otherwise panic with
+	Offset int    // byte offset into the input at which parsing stopped
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Msg + " at offset " + strconv.Itoa(e.Offset)
+}
+
+// Parse decodes a single JSON value like Value, but returns a *SyntaxError
+// instead of panicking on invalid input, including empty input and trailing data.
+func Parse(data []byte) (v any, err error) {
+	raw := Raw(TrimBOM(data))
+	defer recoverSyntaxError(&err, data, &raw)
+	result := raw.Value()
+	raw.EnsureEOF()
+	return result, nil
+}
+
+// ValueAll decodes all whitespace-separated JSON values in data, like a
+// stream of messages or newline-delimited JSON held in memory, returning nil
+// for empty input. Panics on invalid JSON like Value.
+func ValueAll(data []byte) []any {
+	raw := Raw(TrimBOM(data))
+	var result []any
+	for raw.More() {
+		result = append(result, raw.Value())
+	}
+	return result
+}
+
+// Equal reports whether a and b are valid JSON documents with the same value,
+// regardless of whitespace, object key order and number formatting, so that
+// 1, 1.0 and 1e0 are equal. Numbers are compared as float64 values, except
+// ones beyond its range, like 1e400, which compare by their decimal digits,
+// and objects with duplicate keys compare by the last value, like Value.
+func Equal(a, b []byte) bool {
+	va, ok := parseForEqual(a)
+	if !ok {
+		return false
+	}
+	vb, ok := parseForEqual(b)
+	return ok && DeepEqualJSON(va, vb)
+}
+
+func parseForEqual(data []byte) (v any, ok bool) {
+	ok = true
+	d := Decoder{Raw: Raw(data), NumberParser: floatOrDigits, OnError: func(int, string) { ok = false }}
+	v = d.Value()
+	d.EnsureEOF()
+	return v, ok
+}
+
+// bigNumber is a number beyond the range of float64 in the canonical form
+// that floatOrDigits produces.
+type bigNumber string
+
+// floatOrDigits is the NumberParser of Equal, parsing numbers into float64,
+// or into a bigNumber holding their significant digits and exponent, like
+// -15e399 for -1.50e400, if they are beyond its range.
+func floatOrDigits(raw []byte) (any, error) {
+	s := Token(raw).Raw()
+	v, err := strconv.ParseFloat(s, 64)
+	if err == nil || err.(*strconv.NumError).Err != strconv.ErrRange {
+		return v, err
+	}
+	sign := ""
+	if s[0] == '-' {
+		sign = "-"
+	}
+	s = strings.TrimLeft(s, "+-")
+	var exp int64
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		if exp, err = strconv.ParseInt(s[i+1:], 10, 64); err != nil {
+			return bigNumber(sign + s), nil // absurd exponents only match as written
+		}
+		s = s[:i]
+	}
+	intPart, frac, _ := strings.Cut(s, ".")
+	digits := strings.TrimLeft(intPart+frac, "0")
+	exp -= int64(len(frac))
+	for len(digits) > 1 && digits[len(digits)-1] == '0' {
+		digits = digits[:len(digits)-1]
+		exp++
+	}
+	return bigNumber(sign + digits + "e" + strconv.FormatInt(exp, 10)), nil
+}
+
+// DeepEqualJSON reports whether a and b, as returned by Value and similar
+// methods, are deeply equal, like reflect.DeepEqual, except that numbers of
+// any Go numeric types are equal if their values are, e.g. int 30 and
+// float64 30.0. Recurses into map[string]any, []any and []KV (comparing
+// members in order); other values are compared with reflect.DeepEqual.
+func DeepEqualJSON(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, av := range a {
+			if bv, ok := b[k]; !ok || !DeepEqualJSON(av, bv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !DeepEqualJSON(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	case []KV:
+		b, ok := b.([]KV)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i].Key != b[i].Key || !DeepEqualJSON(a[i].Value, b[i].Value) {
+				return false
+			}
+		}
+		return true
+	}
+	if aneg, amag, ok := intParts(a); ok {
+		if bneg, bmag, ok := intParts(b); ok {
+			return aneg == bneg && amag == bmag
+		}
+	}
+	if af, ok := floatValue(a); ok {
+		bf, ok := floatValue(b)
+		return ok && af == bf
+	}
+	return reflect.DeepEqual(a, b) // not ==, which panics on uncomparable types like []int
+}
+
+// intParts splits a value of an integer type into its sign and magnitude, so
+// that signed and unsigned values can be compared exactly.
+func intParts(v any) (neg bool, mag uint64, ok bool) {
+	var i int64
+	switch v := v.(type) {
+	case int:
+		i = int64(v)
+	case int8:
+		i = int64(v)
+	case int16:
+		i = int64(v)
+	case int32:
+		i = int64(v)
+	case int64:
+		i = v
+	case uint:
+		return false, uint64(v), true
+	case uint8:
+		return false, uint64(v), true
+	case uint16:
+		return false, uint64(v), true
+	case uint32:
+		return false, uint64(v), true
+	case uint64:
+		return false, v, true
+	default:
+		return false, 0, false
+	}
+	if i < 0 {
+		return true, uint64(-(i + 1)) + 1, true // avoids overflow for MinInt64
+	}
+	return false, uint64(i), true
+}
+
+func floatValue(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	}
+	if neg, mag, ok := intParts(v); ok {
+		if neg {
+			return -float64(mag), true
+		}
+		return float64(mag), true
+	}
+	return 0, false
+}
+
+// recoverSyntaxError turns a panic raised while parsing data into a *SyntaxError
+// stored in *err, using the remaining raw data to compute the offset.
+// Other panics, which aren't about invalid JSON, propagate unchanged.
+func recoverSyntaxError(err *error, data []byte, raw *Raw) {
+	if e := recover(); e != nil {
+		*err = &SyntaxError{Msg: panicMessage(e), Offset: len(data) - len(*raw)}
+	}
+}
+
+// panicMessage returns the message of a recovered invalid JSON panic, which
+// is always a string, and re-panics with anything else, like a runtime error.
+func panicMessage(e any) string {
+	msg, ok := e.(string)
+	if !ok {
+		panic(e)
+	}
+	return msg
 }
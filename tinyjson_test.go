@@ -1,8 +1,13 @@
 package tinyjson
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"math"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -24,6 +29,7 @@ func TestNext(t *testing.T) {
 		{`scientific notation`, `6.022e23`, `6.022e23`},
 		{`empty string`, `""`, `""`},
 		{`escaped backslash`, `"\\"`, `"\\"`},
+		{`leading plus`, `[+5]`, `[ +5 ]`},
 	}
 
 	for _, test := range tests {
@@ -36,207 +42,1623 @@ func TestNext(t *testing.T) {
 	}
 }
 
+func TestNextComplete(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		ok       bool
+	}{
+		{`empty`, ``, ``, false},
+		{`whitespace`, " \n", ``, false},
+		{`partial string`, ` "abc`, ``, false},
+		{`partial escape`, `"abc\`, ``, false},
+		{`string ending in escaped quote`, `"abc\"`, ``, false},
+		{`partial true`, `tr`, ``, false},
+		{`partial false`, `fals`, ``, false},
+		{`partial null`, `n`, ``, false},
+		{`partial byte order mark`, "\xEF\xBB", ``, false},
+		{`number at end`, `12`, ``, false},
+		{`string`, `"abc" `, `"abc"`, true},
+		{`literal`, `true`, `true`, true},
+		{`terminated number`, `12,`, `12`, true},
+		{`punctuation`, ` {`, `{`, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := Raw(test.input)
+			actual, ok := raw.NextComplete()
+			if actual.Raw() != test.expected || ok != test.ok {
+				t.Errorf("** NextComplete(%q) = %s, %v, wanted %s, %v", test.input, actual, ok, test.expected, test.ok)
+			}
+			if !ok && string(raw) != test.input {
+				t.Errorf("** NextComplete(%q) advanced to %q", test.input, raw)
+			}
+		})
+	}
+
+	ensurePanic(t, func() { raw(`x`).NextComplete() }, "invalid JSON")
+	ensurePanic(t, func() { raw(`tx`).NextComplete() }, "invalid JSON")
+}
+
+func TestNextCompleteChunked(t *testing.T) {
+	input := `{"name": "John \"Doe\"", "tags": [true, false, null], "n": -12.5e3, "x": 7}`
+	expected := strings.Join(allTokens(input), " ")
+	for size := 1; size <= len(input); size++ {
+		var buf []byte
+		var tokens []string
+		for i := 0; i < len(input); i += size {
+			end := i + size
+			if end > len(input) {
+				end = len(input)
+			}
+			buf = append(buf, input[i:end]...)
+			raw := Raw(buf)
+			for {
+				token, ok := raw.NextComplete()
+				if !ok {
+					break
+				}
+				tokens = append(tokens, string(token))
+			}
+			buf = append([]byte(nil), raw...)
+		}
+		tokens = append(tokens, allTokens(string(buf))...)
+		if actual := strings.Join(tokens, " "); actual != expected {
+			t.Errorf("** with %d-byte chunks got %s, wanted %s", size, actual, expected)
+		}
+	}
+}
+
+func TestTokens(t *testing.T) {
+	var actual []string
+	r := Raw(`{"a": [1, true]} null`)
+	r.Tokens()(func(kind Kind, token Token) bool {
+		if kind != token.Kind() {
+			t.Errorf("** kind %c doesn't match token %s", kind, token)
+		}
+		actual = append(actual, token.Raw())
+		return true
+	})
+	if a, e := strings.Join(actual, " "), `{ "a" : [ 1 , true ] } null`; a != e {
+		t.Errorf("** Tokens() yielded %s, wanted %s", a, e)
+	}
+	if len(r) != 0 {
+		t.Errorf("** Tokens() left %q unconsumed", r)
+	}
+
+	r = Raw(`[1, 2, 3]`)
+	r.Tokens()(func(kind Kind, token Token) bool {
+		return kind != Number
+	})
+	if a, e := string(r), `, 2, 3]`; a != e {
+		t.Errorf("** after stopping at the first number, remaining %s, wanted %s", a, e)
+	}
+}
+
+func TestScanners(t *testing.T) {
+	tests := []struct {
+		name      string
+		scan      func([]byte) (Token, []byte)
+		input     string
+		token     string
+		remainder string
+	}{
+		{`ScanToken`, ScanToken, ` {"a":1}`, `{`, `"a":1}`},
+		{`ScanToken string`, ScanToken, `"a\"b",`, `"a\"b"`, `,`},
+		{`ScanToken at EOF`, ScanToken, " \n", ``, ``},
+		{`ScanString`, ScanString, `"a\"b",`, `"a\"b"`, `,`},
+		{`ScanString at end`, ScanString, `""`, `""`, ``},
+		{`ScanNumber`, ScanNumber, `-1.5e+3]`, `-1.5e+3`, `]`},
+		{`ScanNumber at end`, ScanNumber, `42`, `42`, ``},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			token, remainder := test.scan([]byte(test.input))
+			if token.Raw() != test.token || string(remainder) != test.remainder {
+				t.Errorf("** %s(%q) = %q, %q, wanted %q, %q", test.name, test.input, token, remainder, test.token, test.remainder)
+			}
+		})
+	}
+}
+
 func TestSkip(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
-		expected string
+		expected string
+	}{
+		{`true`, `true 42`, "42"},
+		{`number`, `3.141 42`, "42"},
+		{`string`, `"foo bar" 42`, "42"},
+		{`EOF`, `true`, ""},
+		{`object`, `{"name":"John Doe","age":30,"city":"New York"} 42`, "42"},
+		{`nested structure`, `{"name":"John Doe","items":[1,2,3, {"subkey": 123, "test": []}], "city":{"name": "New York"}} 42`, "42"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := Raw(test.input)
+			raw.Skip()
+			actual := raw.Next().Raw()
+			if actual != test.expected {
+				t.Errorf("** Tokens(%v) = %s, wanted %s", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestSkipN(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int
+	}{
+		{`literal`, `true 42`, 4},
+		{`leading whitespace`, "  \n\"foo\" 42", 5},
+		{`object`, `{"a": [1, 2]}, 42`, 13},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := Raw(test.input)
+			actual := raw.SkipN()
+			if actual != test.expected {
+				t.Errorf("** Raw.SkipN(%q) = %d, wanted %d", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestSkipContainer(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		f        func(raw *Raw)
+		expected string
+	}{
+		{`right after start`, `{"a": 1, "b": 2} 42`, func(raw *Raw) { raw.StartObject() }, " 42"},
+		{`before a value`, `{"a": {"b": [1]}, "c": 2} 42`, func(raw *Raw) { raw.StartObject() }, " 42"},
+		{`after a value`, `{"a": 1, "b": {"c": []}} 42`, func(raw *Raw) { raw.StartObject(); raw.Int() }, " 42"},
+		{`empty object`, `{} 42`, func(raw *Raw) { raw.Next() }, " 42"},
+		{`array element`, `[1, [2, [3]], 4] 42`, func(raw *Raw) { raw.StartArray(); raw.ContinueArray(); raw.Int() }, " 42"},
+		{`nested`, `[[1, 2], 3] 42`, func(raw *Raw) { raw.StartArray(); raw.ContinueArray(); raw.StartArray() }, ", 3] 42"},
+		{`brackets in strings`, `["]", "}"] 42`, func(raw *Raw) { raw.StartArray() }, " 42"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := Raw(test.input)
+			test.f(&raw)
+			raw.SkipContainer()
+			if string(raw) != test.expected {
+				t.Errorf("** Raw.SkipContainer() left %q, wanted %q", raw, test.expected)
+			}
+		})
+	}
+}
+
+func TestArrayIndex(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		index    int
+		expected any // the element, or the input left if there is none
+	}{
+		{`first`, `[-73.9, 40.7] 42`, 0, -73.9},
+		{`second`, `[-73.9, 40.7, 10] 42`, 1, 40.7},
+		{`after nested`, `[[1, 2], {"a": []}, "x", 3] 42`, 2, "x"},
+		{`nested element`, `[1, [2, 3]] 42`, 1, []any{2.0, 3.0}},
+		{`too short`, `[1, 2] 42`, 2, " 42"},
+		{`empty`, `[] 42`, 0, " 42"},
+		{`negative`, `[1] 42`, -1, " 42"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := Raw(test.input)
+			if !raw.ArrayIndex(test.index) {
+				if string(raw) != test.expected {
+					t.Errorf("** Raw.ArrayIndex(%d) = false leaving %q, wanted %v", test.index, raw, test.expected)
+				}
+				return
+			}
+			if actual := raw.Value(); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** element %d = %v, wanted %v", test.index, actual, test.expected)
+			}
+			raw.SkipContainer()
+			if string(raw) != " 42" {
+				t.Errorf("** SkipContainer() after ArrayIndex left %q", raw)
+			}
+		})
+	}
+
+	ensurePanic(t, func() { raw(`{}`).ArrayIndex(0) }, "unexpected JSON: {")
+	d := Decoder{Raw: Raw(`[1 2]`), Strict: true}
+	ensurePanic(t, func() { d.ArrayIndex(1) }, "invalid JSON: missing comma")
+	d = Decoder{Raw: Raw(`[1, 2]`), Strict: true}
+	if !d.ArrayIndex(1) || d.Int() != 2 {
+		t.Errorf("** Decoder.ArrayIndex(1) failed")
+	}
+}
+
+func TestOffset(t *testing.T) {
+	src := []byte(` {"a": [true, null, -1.5, "x\ny"]} `)
+	raw := Raw(src)
+	var actual []string
+	for tok := raw.Next(); tok != nil; tok = raw.Next() {
+		start, end := tok.Range(src)
+		actual = append(actual, fmt.Sprintf("%d-%d:%s", tok.Offset(src), end, src[start:end]))
+	}
+	expected := []string{"1-2:{", "2-5:\"a\"", "5-6::", "7-8:[", "8-12:true", "12-13:,", "14-18:null", "18-19:,", "20-24:-1.5", "24-25:,", "26-32:\"x\\ny\"", "32-33:]", "33-34:}"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** token ranges = %q, wanted %q", actual, expected)
+	}
+
+	tok, _ := Raw(src).Pointer("/a")
+	if start, end := tok.Range(src); start != 7 || end != 33 {
+		t.Errorf("** Range of /a = %d, %d, wanted 7, 33", start, end)
+	}
+}
+
+func TestStr(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    Token
+		expected string
+	}{
+		{`string token`, Token(`"hello"`), "hello"},
+		{`empty string`, Token(`""`), ""},
+		{`escape double quote`, Token(`"\""`), `"`},
+		{`escape backslash`, Token(`"\\"`), `\`},
+		{`escape forward slash`, Token(`"\/"`), "/"},
+		{`escape backspace`, Token(`"\b"`), "\b"},
+		{`escape form feed`, Token(`"\f"`), "\f"},
+		{`escape newline`, Token(`"\n"`), "\n"},
+		{`escape carriage return`, Token(`"\r"`), "\r"},
+		{`escape tab`, Token(`"\t"`), "\t"},
+		{`multiple escapes`, Token(`"\n\t\f"`), "\n\t\f"},
+		{`escapes with other characters`, Token(`"foo\nbar\tboz\t\\fubar\ffizboz"`), "foo\nbar\tboz\t\\fubar\ffizboz"},
+		{`unicode escape`, Token(`"\u263A"`), "☺"},
+		{`unknown escape`, Token(`"a\xb"`), "axb"},
+		{`true`, Token("true"), "true"},
+		{`false`, Token("false"), "false"},
+		{`null`, Token("null"), ""},
+		{`EOF`, Token(nil), ""},
+		{`int`, Token("42"), "42"},
+		{`float`, Token("3.141"), "3.141"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.token.Str()
+			if actual != test.expected {
+				t.Errorf("** Token.String(%s) = %s, wanted %s", test.token, actual, test.expected)
+			}
+			if actual := string(test.token.StrAppend([]byte(">"), false)); actual != ">"+test.expected {
+				t.Errorf("** Token.StrAppend(%s) = %s, wanted >%s", test.token, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestHasEscape(t *testing.T) {
+	tests := []struct {
+		token    Token
+		expected bool
+	}{
+		{Token(`"plain"`), false},
+		{Token(`""`), false},
+		{Token(`"a\nb"`), true},
+		{Token(`"\\"`), true},
+		{Token(`"\u263A"`), true},
+		{Token(`123`), false},
+		{Token(`null`), false},
+		{Token(nil), false},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.token), func(t *testing.T) {
+			if actual := test.token.HasEscape(); actual != test.expected {
+				t.Errorf("** Token.HasEscape(%s) = %v, wanted %v", test.token, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestIntExact(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		exact    bool
+	}{
+		{`3`, 3, true},
+		{`-3`, -3, true},
+		{`+3`, 3, true},
+		{`0`, 0, true},
+		{`-0`, 0, true},
+		{`3.0`, 3, true},
+		{`3.000`, 3, true},
+		{`3.`, 3, true},
+		{`1e3`, 1000, true},
+		{`1E+3`, 1000, true},
+		{`1.5e1`, 15, true},
+		{`1500e-2`, 15, true},
+		{`-1.25e2`, -125, true},
+		{`0.0e-5`, 0, true},
+		{`0e999999999999`, 0, true},
+		{`9223372036854775807`, math.MaxInt64, true},
+		{`-9223372036854775808`, math.MinInt64, true},
+		{`9.223372036854775807e18`, math.MaxInt64, true},
+		{`922337203685477580.7e1`, math.MaxInt64, true},
+		{`3.7`, 3, false},
+		{`-3.7`, -3, false},
+		{`-35e-1`, -3, false},
+		{`.5`, 0, false},
+		{`1e-999999999999`, 0, false},
+		{`1.05e1`, 10, false},
+		{`9223372036854775808`, 0, false},
+		{`-9223372036854775809`, 0, false},
+		{`1e19`, 0, false},
+		{`1e999999999999`, 0, false},
+		{`100000000000000000000e-2`, 1e18, true},
+		{`1000000000000000000000e-2`, 0, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			actual, exact := raw(test.input).IntExact()
+			if actual != test.expected || exact != test.exact {
+				t.Errorf("** Raw.IntExact(%s) = %d, %v, wanted %d, %v", test.input, actual, exact, test.expected, test.exact)
+			}
+			d := Decoder{Raw: Raw(test.input)}
+			if actual, exact := d.IntExact(); actual != test.expected || exact != test.exact {
+				t.Errorf("** Decoder.IntExact(%s) = %d, %v, wanted %d, %v", test.input, actual, exact, test.expected, test.exact)
+			}
+		})
+	}
+
+	for _, input := range []string{`"3"`, `null`, `1e`, `1ex`, `1.2.3`, `1-2`, `.`, `-`, `--1`} {
+		ensurePanic(t, func() { Token(input).IntExact() }, "unexpected JSON: "+input)
+	}
+}
+
+func TestIsSafeInteger(t *testing.T) {
+	tests := []struct {
+		token    Token
+		expected bool
+	}{
+		{Token(`0`), true},
+		{Token(`-42`), true},
+		{Token(`9007199254740991`), true},
+		{Token(`-9007199254740991`), true},
+		{Token(`9007199254740992`), false},
+		{Token(`-9007199254740992`), false},
+		{Token(`18446744073709551616`), false},
+		{Token(`1.0`), false},
+		{Token(`1e3`), false},
+		{Token(`+5`), false},
+		{Token(`"1"`), false},
+		{Token(nil), false},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.token), func(t *testing.T) {
+			if actual := test.token.IsSafeInteger(); actual != test.expected {
+				t.Errorf("** Token.IsSafeInteger(%s) = %v, wanted %v", test.token, actual, test.expected)
+			}
+		})
+	}
+	if f := float64(MaxSafeInteger); f+1 == f || int64(f) != MaxSafeInteger {
+		t.Errorf("** MaxSafeInteger is not exactly representable")
+	}
+}
+
+func TestFitsInt(t *testing.T) {
+	tests := []struct {
+		token     Token
+		bits      int
+		int, uint bool
+	}{
+		{Token(`127`), 8, true, true},
+		{Token(`128`), 8, false, true},
+		{Token(`-128`), 8, true, false},
+		{Token(`-129`), 8, false, false},
+		{Token(`255`), 8, false, true},
+		{Token(`256`), 8, false, false},
+		{Token(`+5`), 8, true, true},
+		{Token(`1.2e2`), 8, true, true},
+		{Token(`1.5e2`), 8, false, true},
+		{Token(`2.56e2`), 8, false, false},
+		{Token(`3.0`), 8, true, true},
+		{Token(`-1e0`), 8, true, false},
+		{Token(`-0.0`), 8, true, true},
+		{Token(`1.5`), 16, false, false},
+		{Token(`32767`), 16, true, true},
+		{Token(`32768`), 16, false, true},
+		{Token(`9223372036854775807`), 64, true, true},
+		{Token(`9223372036854775808`), 64, false, true},
+		{Token(`18446744073709551616`), 64, false, false},
+		{Token(`1.8e19`), 64, false, true},
+		{Token(`1.85e19`), 64, false, false},
+		{Token(`1e30`), 64, false, false},
+		{Token(`2147483648`), 0, true, true},
+		{Token(`1e3`), 0, true, true},
+		{Token(`"1"`), 64, false, false},
+		{Token(nil), 64, false, false},
+	}
+
+	for _, test := range tests {
+		if actual := test.token.FitsInt(test.bits); actual != test.int {
+			t.Errorf("** Token.FitsInt(%s, %d) = %v, wanted %v", test.token, test.bits, actual, test.int)
+		}
+		if actual := test.token.FitsUint(test.bits); actual != test.uint {
+			t.Errorf("** Token.FitsUint(%s, %d) = %v, wanted %v", test.token, test.bits, actual, test.uint)
+		}
+	}
+}
+
+func TestEscapeCount(t *testing.T) {
+	tests := []struct {
+		token    Token
+		expected int
+	}{
+		{Token(`""`), 0},
+		{Token(`"plain"`), 0},
+		{Token(`"a\nb"`), 1},
+		{Token(`"\\\\"`), 2},
+		{Token(`"\"\\"`), 2},
+		{Token(`"\u0061\u0062\u0063"`), 3},
+		{Token(`"\x\/"`), 2},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.token), func(t *testing.T) {
+			if actual := test.token.EscapeCount(); actual != test.expected {
+				t.Errorf("** Token.EscapeCount(%s) = %d, wanted %d", test.token, actual, test.expected)
+			}
+		})
+	}
+
+	ensurePanic(t, func() { Token(`1`).EscapeCount() }, "unexpected JSON: 1")
+}
+
+func TestSafeStr(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"plain"`, "plain"},
+		{`"a\nb"`, "a\nb"},
+		{`""`, ""},
+		{`42`, "42"},
+		{`true`, "true"},
+		{`null`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			data := []byte(test.input)
+			raw := Raw(data)
+			actual := raw.SafeStr()
+			for i := range data {
+				data[i] = 'X'
+			}
+			if actual != test.expected {
+				t.Errorf("** Raw.SafeStr(%s) = %q after overwriting the input, wanted %q", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestInputNotModified(t *testing.T) {
+	const input = `{"a\u0062": [1, -2.5e3, "x\ty", true, null], "c": {"d": " e "}, "f": "2024-01-02"}`
+	data := []byte(input)
+	d := Decoder{Raw: Raw(data), Strict: true, InternKeys: true}
+	d.Value()
+	raw := Raw(data)
+	raw.ValueOrdered()
+	raw = Raw(data)
+	raw.Visit(&recordingVisitor{})
+	for raw = Raw(data); raw.Peek() != EOF; {
+		if t := raw.Next(); t.Kind() == String {
+			t.Str()
+			t.StrTrimmed()
+			t.StrAppend(nil, true)
+		}
+	}
+	if string(data) != input {
+		t.Errorf("** input modified to %s", data)
+	}
+}
+
+func TestStrTrimmed(t *testing.T) {
+	tests := []struct {
+		token    Token
+		expected string
+	}{
+		{Token(`"  active  "`), "active"},
+		{Token(`"active"`), "active"},
+		{Token(`" a b "`), "a b"},
+		{Token("\"\t\r\n\v\f x\""), "x"},
+		{Token(`"   "`), ""},
+		{Token(`""`), ""},
+		{Token(`" \t\u00e9\n "`), "é"},
+		{Token(`"\u0020\u0020"`), ""},
+		{Token(`" \u00a0 "`), "\u00a0"},
+		{Token(`"a\ "`), "a"},
+		{Token(`" a\\ "`), "a\\"},
+		{Token(`"\t\\t"`), "\\t"},
+		{Token(`42`), "42"},
+		{Token(`null`), ""},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.token), func(t *testing.T) {
+			if actual := test.token.StrTrimmed(); actual != test.expected {
+				t.Errorf("** Token.StrTrimmed(%s) = %q, wanted %q", test.token, actual, test.expected)
+			}
+			raw := Raw(test.token)
+			if actual := raw.StrTrimmed(); actual != test.expected {
+				t.Errorf("** Raw.StrTrimmed(%s) = %q, wanted %q", test.token, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestRawStr(t *testing.T) {
+	tests := []struct {
+		token    Token
+		expected string
+	}{
+		{Token(`"plain"`), "plain"},
+		{Token(`""`), ""},
+		{Token(`"a\nb\u263A"`), `a\nb\u263A`},
+		{Token(`"\""`), `\"`},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.token), func(t *testing.T) {
+			if actual := test.token.RawStr(); actual != test.expected {
+				t.Errorf("** Token.RawStr(%s) = %s, wanted %s", test.token, actual, test.expected)
+			}
+		})
+	}
+
+	ensurePanic(t, func() { Token(`123`).RawStr() }, "unexpected JSON: 123")
+	ensurePanic(t, func() { Token(nil).RawStr() }, "unexpected end of JSON")
+}
+
+func TestStrUnicodeEscapeAtEnd(t *testing.T) {
+	for _, s := range []string{`"\u"`, `"\u1"`, `"\u12"`, `"\u123"`, `"x\u123"`} {
+		t.Run(s, func(t *testing.T) {
+			data := make([]byte, len(s)) // no spare capacity to read into
+			copy(data, s)
+			ensurePanic(t, func() { Token(data).Str() }, "invalid JSON")
+			ensurePanic(t, func() { appendUnescaped(nil, data[1:len(data)-1]) }, "invalid JSON")
+		})
+	}
+	if actual := Token(`"x\u263A"`).Str(); actual != "x☺" {
+		t.Errorf("** Str = %q, wanted x☺", actual)
+	}
+}
+
+func TestStrAppendStrict(t *testing.T) {
+	token := Token(`"\"\\\/\b\f\n\r\t\u263a\u263A!"`)
+	actual := string(token.StrAppend(nil, true))
+	if expected := "\"\\/\b\f\n\r\t☺☺!"; actual != expected {
+		t.Errorf("** Token.StrAppend(%s, true) = %q, wanted %q", token, actual, expected)
+	}
+}
+
+func TestLiteralTokens(t *testing.T) {
+	for _, test := range []struct {
+		token Token
+		kind  Kind
+		value any
+	}{
+		{TrueToken, True, true},
+		{FalseToken, False, false},
+		{NullToken, Null, nil},
+	} {
+		if test.token.Kind() != test.kind || test.token.Scalar() != test.value {
+			t.Errorf("** %s has kind %c and value %v", test.token, test.token.Kind(), test.token.Scalar())
+		}
+		if actual := raw(` ` + test.token.Raw()).Next(); !bytes.Equal(actual, test.token) {
+			t.Errorf("** Next() = %s, wanted %s", actual, test.token)
+		}
+	}
+}
+
+func TestAppendTo(t *testing.T) {
+	dst := []byte("x=")
+	for _, token := range []Token{Token(`"a\nb"`), nil, Token(`,`), Token(`-1.5e3`)} {
+		dst = token.AppendTo(dst)
+	}
+	if actual, expected := string(dst), `x="a\nb",-1.5e3`; actual != expected {
+		t.Errorf("** AppendTo = %s, wanted %s", actual, expected)
+	}
+}
+
+func TestInt64(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    Token
+		expected int64
+	}{
+		{`positive number`, Token("123"), 123},
+		{`negative number`, Token("-456"), -456},
+		{`zero`, Token("0"), 0},
+		{`int64 max`, Token("9223372036854775807"), 9223372036854775807},
+		{`int64 min`, Token("-9223372036854775808"), -9223372036854775808},
+		{`leading plus`, Token("+5"), 5},
+		{`leading zeros`, Token("007"), 7},
+		{`exponent`, Token("1e3"), 1000},
+		{`fraction and exponent`, Token("1.5e1"), 15},
+		{`zero fraction`, Token("-3.0"), -3},
+		{`large in scientific notation`, Token("9.007199254740993e15"), 9007199254740993},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.token.Int64()
+			if actual != test.expected {
+				t.Errorf("** Token.Int64(%v) = %d, wanted %d", test.token, actual, test.expected)
+			}
+		})
+	}
+	if actual := Token(`4.2e1`).Int(); actual != 42 {
+		t.Errorf("** Token.Int(4.2e1) = %d, wanted 42", actual)
+	}
+	if actual := Token(`-1.28e2`).Int8(); actual != -128 {
+		t.Errorf("** Token.Int8(-1.28e2) = %d, wanted -128", actual)
+	}
+}
+
+func TestUint64(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    Token
+		expected uint64
+	}{
+		{`zero`, Token("0"), 0},
+		{`positive number`, Token("123"), 123},
+		{`max uint64`, Token("18446744073709551615"), 18446744073709551615},
+		{`leading plus`, Token("+5"), 5},
+		{`leading zeros`, Token("007"), 7},
+		{`exponent`, Token("1e3"), 1000},
+		{`zero fraction`, Token("3.0"), 3},
+		{`beyond int64 with exponent`, Token("1.8446744073709551615e19"), 18446744073709551615},
+		{`negative zero`, Token("-0.0"), 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.token.Uint64()
+			if actual != test.expected {
+				t.Errorf("** Token.Uint64(%v) = %d, wanted %d", test.token, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestSizedInts(t *testing.T) {
+	int8f := func(raw *Raw) any { return raw.Int8() }
+	int16f := func(raw *Raw) any { return raw.Int16() }
+	int32f := func(raw *Raw) any { return raw.Int32() }
+	uint8f := func(raw *Raw) any { return raw.Uint8() }
+	uint16f := func(raw *Raw) any { return raw.Uint16() }
+	uint32f := func(raw *Raw) any { return raw.Uint32() }
+	tests := []struct {
+		name     string
+		input    string
+		f        func(raw *Raw) any
+		expected any // nil if it must panic
+	}{
+		{`Int8 min`, `-128`, int8f, int8(-128)},
+		{`Int8 max`, `127`, int8f, int8(127)},
+		{`Int8 below min`, `-129`, int8f, nil},
+		{`Int8 above max`, `128`, int8f, nil},
+		{`Int16 min`, `-32768`, int16f, int16(-32768)},
+		{`Int16 max`, `32767`, int16f, int16(32767)},
+		{`Int16 below min`, `-32769`, int16f, nil},
+		{`Int16 above max`, `32768`, int16f, nil},
+		{`Int32 min`, `-2147483648`, int32f, int32(-2147483648)},
+		{`Int32 max`, `2147483647`, int32f, int32(2147483647)},
+		{`Int32 below min`, `-2147483649`, int32f, nil},
+		{`Int32 above max`, `2147483648`, int32f, nil},
+		{`Uint8 min`, `0`, uint8f, uint8(0)},
+		{`Uint8 max`, `255`, uint8f, uint8(255)},
+		{`Uint8 negative`, `-1`, uint8f, nil},
+		{`Uint8 above max`, `256`, uint8f, nil},
+		{`Uint16 max`, `65535`, uint16f, uint16(65535)},
+		{`Uint16 leading plus`, `+7`, uint16f, uint16(7)},
+		{`Uint16 above max`, `65536`, uint16f, nil},
+		{`Uint32 max`, `4294967295`, uint32f, uint32(4294967295)},
+		{`Uint32 above max`, `4294967296`, uint32f, nil},
+		{`Int8 fraction`, `1.5`, int8f, nil},
+		{`Int16 string`, `"1"`, int16f, nil},
+		{`Uint32 null`, `null`, uint32f, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if test.expected == nil {
+				ensurePanic(t, func() { test.f(raw(test.input)) }, "unexpected JSON: "+test.input)
+				return
+			}
+			actual := test.f(raw(test.input))
+			if actual != test.expected {
+				t.Errorf("** %s = %v (%T), wanted %v (%T)", test.input, actual, actual, test.expected, test.expected)
+			}
+		})
+	}
+}
+
+func TestFloat(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    Token
+		expected float64
+	}{
+		{`positive float`, Token("3.14"), 3.14},
+		{`negative float`, Token("-2.718"), -2.718},
+		{`zero`, Token("0.0"), 0.0},
+		{`scientific notation`, Token("6.022e23"), 6.022e23},
+		{`leading plus`, Token("+5"), 5},
+		{`leading zero`, Token("01.5"), 1.5},
+		{`leading decimal point`, Token(".5"), 0.5},
+		{`negative leading decimal point`, Token("-.5"), -0.5},
+		{`trailing decimal point`, Token("5."), 5},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.token.Float()
+			if actual != test.expected {
+				t.Errorf("** Token.Float64(%v) = %g, wanted %g", test.token, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestFloatFast(t *testing.T) {
+	inputs := []string{
+		"0", "-0", "0.0", "-0.0", "3.14", "-2.718", "12.5", "0.1", "0.3", "-0.004", "100", "007.50",
+		"123456789012345", "0.123456789012345", "999999999999999", "9.99999999999999",
+		"1234567890123456", "0.1234567890123456", "6.022e23", "1E-7", "+5", ".5", "-.5", "5.", "1.2.3",
+	}
+	for _, input := range inputs {
+		expected, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			ensurePanic(t, func() { Token(input).FloatFast() }, "unexpected JSON: "+input)
+			continue
+		}
+		if actual := Token(input).FloatFast(); math.Float64bits(actual) != math.Float64bits(expected) {
+			t.Errorf("** Token(%s).FloatFast() = %g, wanted %g", input, actual, expected)
+		}
+	}
+	if actual := raw(`2.25`).FloatFast(); actual != 2.25 {
+		t.Errorf("** Raw.FloatFast() = %g, wanted 2.25", actual)
+	}
+	ensurePanic(t, func() { Token(`"1"`).FloatFast() }, `unexpected JSON: "1"`)
+	ensurePanic(t, func() { Token(`-`).FloatFast() }, `unexpected JSON: -`)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		Token("-1234.5678").FloatFast()
+	})
+	if allocs != 0 {
+		t.Errorf("** FloatFast made %v allocations, wanted 0", allocs)
+	}
+}
+
+func FuzzFloatFast(f *testing.F) {
+	f.Add("12.5")
+	f.Add("-0.004")
+	f.Add("123456789012345")
+	f.Add("1e5")
+	f.Fuzz(func(t *testing.T, s string) {
+		expected, err := strconv.ParseFloat(s, 64)
+		if err != nil || Token(s).Kind() != Number {
+			return
+		}
+		if actual := Token(s).FloatFast(); math.Float64bits(actual) != math.Float64bits(expected) {
+			t.Errorf("** Token(%s).FloatFast() = %g, wanted %g", s, actual, expected)
+		}
+	})
+}
+
+func TestFloatLenient(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+	}{
+		{`number`, `3.14`, 3.14},
+		{`string`, `"3.14"`, 3.14},
+		{`negative string`, `"-2e3"`, -2e3},
+		{`escaped string`, `"\u0031"`, 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := raw(test.input).FloatLenient()
+			if actual != test.expected {
+				t.Errorf("** Raw.FloatLenient(%s) = %g, wanted %g", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestNumberCanonical(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1`, "1"},
+		{`1.0`, "1"},
+		{`1e0`, "1"},
+		{`10E-1`, "1"},
+		{`-0`, "0"},
+		{`-0.0`, "0"},
+		{`0.5`, "0.5"},
+		{`-2.50`, "-2.5"},
+		{`100000000000`, "100000000000"},
+		{`1e11`, "100000000000"},
+		{`1e21`, "1e+21"},
+		{`0.000001`, "0.000001"},
+		{`0.0000001`, "1e-7"},
+		{`9007199254740993`, "9007199254740992"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.input, func(t *testing.T) {
+			actual := raw(test.input).NumberCanonical()
+			if actual != test.expected {
+				t.Errorf("** Raw.NumberCanonical(%s) = %q, wanted %q", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestBool(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    Token
+		expected bool
+	}{
+		{`true`, Token("true"), true},
+		{`false`, Token("false"), false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.token.Bool()
+			if actual != test.expected {
+				t.Errorf("** Token.Bool(%v) = %t, wanted %t", test.token, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestKeyIs(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    Token
+		key      string
+		expected bool
+	}{
+		{`equal`, Token(`"name"`), "name", true},
+		{`different`, Token(`"name"`), "nam", false},
+		{`empty`, Token(`""`), "", true},
+		{`escaped equal`, Token(`"\u0061b"`), "ab", true},
+		{`escaped different`, Token(`"\u0061b"`), `\u0061b`, false},
+		{`escaped quote`, Token(`"a\"b"`), `a"b`, true},
+		{`raw backslash in key`, Token(`"a\\b"`), `a\b`, true},
+		{`non-ASCII`, Token(`"☺"`), "☺", true},
+		{`non-string`, Token(`true`), "true", false},
+		{`EOF`, nil, "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.token.KeyIs(test.key)
+			if actual != test.expected {
+				t.Errorf("** Token(%s).KeyIs(%q) = %v, wanted %v", test.token, test.key, actual, test.expected)
+			}
+		})
+	}
+
+	if n := testing.AllocsPerRun(10, func() { Token(`"name"`).KeyIs("name") }); n != 0 {
+		t.Errorf("** KeyIs allocated %v times", n)
+	}
+}
+
+func TestEnum(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{`first`, `"active"`, "active"},
+		{`last`, `"deleted"`, "deleted"},
+		{`escaped`, `"\u0061ctive"`, "active"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := raw(test.input).Enum("active", "suspended", "deleted")
+			if actual != test.expected {
+				t.Errorf("** Raw.Enum(%s) = %s, wanted %s", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestEnumFold(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{`exact`, `"active"`, "active"},
+		{`capitalized`, `"Active"`, "active"},
+		{`upper case`, `"DELETED"`, "deleted"},
+		{`escaped`, `"\u0041ctive"`, "active"},
+		{`non-ASCII`, `"ÉTÉ"`, "été"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := raw(test.input).EnumFold("active", "deleted", "été")
+			if actual != test.expected {
+				t.Errorf("** Raw.EnumFold(%s) = %s, wanted %s", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestTokenCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected int
+	}{
+		{`empty`, ``, 0},
+		{`whitespace`, "  \n", 0},
+		{`scalar`, `"hello"`, 1},
+		{`array`, `[1, 2, 3]`, 7},
+		{`object`, `{"a": [true, null], "b": {}}`, 14},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := TokenCount([]byte(test.input))
+			if actual != test.expected {
+				t.Errorf("** TokenCount(%s) = %d, wanted %d", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestNestingDepth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{`"hello"`, 0},
+		{` 42 [1]`, 0},
+		{`[]`, 1},
+		{`{"a": 1, "b": 2}`, 1},
+		{`[[1], {}]`, 2},
+		{`{"a": [{"b": [[]]}], "c": {}} {}`, 5},
+		{`[[[[[[[[[[]]]]]]]]]]`, 10},
+	}
+
+	for _, test := range tests {
+		raw := Raw(test.input)
+		if actual := raw.NestingDepth(); actual != test.expected {
+			t.Errorf("** NestingDepth(%s) = %d, wanted %d", test.input, actual, test.expected)
+		}
+		if string(raw) != test.input {
+			t.Errorf("** NestingDepth(%s) consumed input", test.input)
+		}
+	}
+
+	ensurePanic(t, func() { raw(``).NestingDepth() }, "unexpected end of JSON")
+	ensurePanic(t, func() { raw(`[[1]`).NestingDepth() }, "unexpected end of JSON")
+	ensurePanic(t, func() { raw(`]`).NestingDepth() }, "invalid JSON")
+	ensurePanic(t, func() { raw(`, 1`).NestingDepth() }, "invalid JSON")
+	ensurePanic(t, func() { raw(`[1}`).NestingDepth() }, "invalid JSON")
+	ensurePanic(t, func() { raw(`{"a": 1]`).NestingDepth() }, "invalid JSON")
+	ensurePanic(t, func() { raw(`[[]`).NestingDepth() }, "unexpected end of JSON")
+	ensurePanic(t, func() { raw(`{"a"}`).NestingDepth() }, "invalid JSON")
+}
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    Token
+		expected uint64
+	}{
+		{`EOF`, nil, 0xcbf29ce484222325},
+		{`string`, Token(`"a"`), fnv64a(`"a"`)},
+		{`escaped string`, Token(`"\u0061"`), fnv64a(`"\u0061"`)},
+		{`number`, Token(`42`), fnv64a(`42`)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := test.token.Hash()
+			if actual != test.expected {
+				t.Errorf("** Token.Hash(%s) = %x, wanted %x", test.token, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected any
+	}{
+		{`null`, `null`, nil},
+		{`true`, `true`, true},
+		{`false`, `false`, false},
+		{`integer`, `123`, 123.0},
+		{`float`, `3.14`, 3.14},
+		{`scientific notation`, `6.022e23`, 6.022e23},
+		{`string`, `"Hello, World!"`, "Hello, World!"},
+		{`array`, `[1, "two", 3.0, true, null]`, []any{1.0, "two", 3.0, true, nil}},
+		{`object`, `{"name":"John", "age":30, "city":"New York"}`, map[string]any{"name": "John", "age": 30.0, "city": "New York"}},
+		{`nested object`, `{"person":{"name":"John", "age":30}, "city":"New York"}`, map[string]any{"person": map[string]any{"name": "John", "age": 30.0}, "city": "New York"}},
+		{`nested array`, `[1, [2, 3], 4]`, []any{1.0, []any{2.0, 3.0}, 4.0}},
+		{`empty containers`, `[{}, [], {"a": [[]], "b": {}}]`, []any{map[string]any{}, []any(nil), map[string]any{"a": []any{[]any(nil)}, "b": map[string]any{}}}},
+		{`empty array`, `[]`, []any(nil)},
+		{`duplicate keys`, `{"a": 1, "a": 2}`, map[string]any{"a": 2.0}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := Raw(test.input)
+			actual := raw.Value()
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** Raw.Value() = %v, wanted %v", actual, test.expected)
+			}
+			raw = Raw(test.input)
+			actual = raw.ValueIter()
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** Raw.ValueIter() = %v, wanted %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestValueIterDeep(t *testing.T) {
+	const depth = 100000
+	input := strings.Repeat(`{"a":[`, depth) + strings.Repeat(`]}`, depth)
+	v := raw(input).ValueIter()
+	for i := 0; i < depth; i++ {
+		v = v.(map[string]any)["a"].([]any)
+		if i < depth-1 {
+			v = v.([]any)[0]
+		}
+	}
+	if v.([]any) != nil {
+		t.Errorf("** innermost value is %v, wanted empty array", v)
+	}
+}
+
+func TestValueRecursionDepth(t *testing.T) {
+	defer func(saved int) { ValueRecursionDepth = saved }(ValueRecursionDepth)
+
+	const input = `{"a": [1, {"b": [[], {}, [2, {"c": null}]]}], "d": "x"}`
+	expected := raw(input).ValueIter()
+	for _, depth := range []int{0, 1, 2, 3, 5, 100} {
+		ValueRecursionDepth = depth
+		raw := raw(input)
+		if actual := raw.Value(); !reflect.DeepEqual(actual, expected) {
+			t.Errorf("** Value() with ValueRecursionDepth = %d returned %v, wanted %v", depth, actual, expected)
+		}
+		raw.EnsureEOF()
+	}
+
+	const deep = 100000
+	ValueRecursionDepth = 100
+	v := raw(strings.Repeat(`[`, deep) + strings.Repeat(`]`, deep)).Value()
+	for i := 0; i < deep-1; i++ {
+		v = v.([]any)[0]
+	}
+	if v.([]any) != nil {
+		t.Errorf("** innermost value is %v, wanted empty array", v)
+	}
+
+	d := Decoder{Raw: Raw(`[[[[1]]]]`), MaxDepth: 3}
+	ValueRecursionDepth = 2
+	ensurePanic(t, func() { d.Raw.value(&d) }, "invalid JSON: nesting exceeds maximum depth")
+}
+
+func TestValueCanonical(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{`scalar`, `  "x" `, `"x"`},
+		{`sorted keys`, `{"z": 1, "a": [true, {"y": null, "b": 2.50}], "m": "\u0041"}`, `{"a":[true,{"b":2.5,"y":null}],"m":"A","z":1}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := string(AppendValue(nil, raw(test.input).ValueCanonical()))
+			if actual != test.expected {
+				t.Errorf("** canonical %s = %s, wanted %s", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestValueOrdered(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected any
+	}{
+		{`scalar`, `"Hello"`, "Hello"},
+		{`object`, `{"z":1, "a":2, "m":3}`, []KV{{"z", 1.0}, {"a", 2.0}, {"m", 3.0}}},
+		{`empty object`, `{}`, []KV(nil)},
+		{`duplicate keys`, `{"a":1, "a":2}`, []KV{{"a", 1.0}, {"a", 2.0}}},
+		{`nested`, `[{"b":[true, null], "a":{"y":"x"}}]`, []any{[]KV{{"b", []any{true, nil}}, {"a", []KV{{"y", "x"}}}}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			raw := Raw(test.input)
+			actual := raw.ValueOrdered()
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** Raw.ValueOrdered() = %v, wanted %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestValueAs(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		kind     Kind
+		expected any
+	}{
+		{`string`, `"x"`, String, "x"},
+		{`number`, `-1.5`, Number, -1.5},
+		{`true`, `true`, True, true},
+		{`false as True`, `false`, True, false},
+		{`true as False`, `true`, False, true},
+		{`null`, `null`, Null, nil},
+		{`object`, `{"a": [1]}`, StartObject, map[string]any{"a": []any{1.0}}},
+		{`array`, `["a"]`, StartArray, []any{"a"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := raw(test.input).ValueAs(test.kind)
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** Raw.ValueAs(%c) = %#v, wanted %#v", test.kind, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestValueAsMismatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		kind     Kind
+		expected string
+	}{
+		{`number as string`, `42`, String, "unexpected JSON: 42"},
+		{`string as number`, `"42"`, Number, `unexpected JSON: "42"`},
+		{`null as string`, `null`, String, "unexpected JSON: null"},
+		{`bool as null`, `true`, Null, "unexpected JSON: true"},
+		{`array as object`, `[]`, StartObject, "unexpected JSON: ["},
+		{`object as array`, `{}`, StartArray, "unexpected JSON: {"},
+		{`eof`, ``, String, "unexpected end of JSON"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ensurePanic(t, func() { raw(test.input).ValueAs(test.kind) }, test.expected)
+		})
+	}
+}
+
+func TestCaptureExtra(t *testing.T) {
+	type foo struct {
+		Name  string
+		Extra map[string]any
+	}
+	decode := func(raw *Raw) (f foo) {
+		for key := raw.StartObject(); key != nil; key = raw.ContinueObject() {
+			switch key.Str() {
+			case "name":
+				f.Name = raw.Str()
+			default:
+				if f.Extra == nil {
+					f.Extra = make(map[string]any)
+				}
+				raw.CaptureExtra(f.Extra, key)
+			}
+		}
+		return f
+	}
+
+	r := raw(`{"x\u0031": [1, {"y": null}], "name": "a", "z": true}`)
+	actual := decode(r)
+	r.EnsureEOF()
+	expected := foo{"a", map[string]any{"x1": []any{1.0, map[string]any{"y": nil}}, "z": true}}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("** decoded %v, wanted %v", actual, expected)
+	}
+	if actual := decode(raw(`{"name": "b"}`)); actual.Extra != nil {
+		t.Errorf("** Extra = %v, wanted nil", actual.Extra)
+	}
+
+	d := Decoder{Raw: Raw(`{"x": 1}`), NumberParser: IntOrFloat}
+	extra := make(map[string]any)
+	for key := d.StartObject(); key != nil; key = d.ContinueObject() {
+		d.CaptureExtra(extra, key)
+	}
+	if !reflect.DeepEqual(extra, map[string]any{"x": int64(1)}) {
+		t.Errorf("** Decoder.CaptureExtra captured %v", extra)
+	}
+}
+
+func TestValueTyped(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected any
 	}{
-		{`true`, `true 42`, "42"},
-		{`number`, `3.141 42`, "42"},
-		{`string`, `"foo bar" 42`, "42"},
-		{`EOF`, `true`, ""},
-		{`object`, `{"name":"John Doe","age":30,"city":"New York"} 42`, "42"},
-		{`nested structure`, `{"name":"John Doe","items":[1,2,3, {"subkey": 123, "test": []}], "city":{"name": "New York"}} 42`, "42"},
+		{`int`, `42`, int64(42)},
+		{`negative`, `-7`, int64(-7)},
+		{`int64 max`, `9223372036854775807`, int64(math.MaxInt64)},
+		{`int64 max + 1`, `9223372036854775808`, 9223372036854775808.0},
+		{`int64 min`, `-9223372036854775808`, int64(math.MinInt64)},
+		{`int64 min - 1`, `-9223372036854775809`, -9223372036854775809.0},
+		{`fraction`, `1.0`, 1.0},
+		{`exponent`, `1e2`, 100.0},
+		{`overflow`, `1e400`, math.Inf(1)},
+		{`negative overflow`, `-1e400`, math.Inf(-1)},
+		{`underflow`, `1e-400`, 0.0},
+		{`nested`, `{"a": [1, 2.5, "x", null], "b": {"c": 18446744073709551616}}`, map[string]any{"a": []any{int64(1), 2.5, "x", nil}, "b": map[string]any{"c": 18446744073709551616.0}}},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			raw := Raw(test.input)
-			raw.Skip()
-			actual := raw.Next().Raw()
-			if actual != test.expected {
-				t.Errorf("** Tokens(%v) = %s, wanted %s", test.input, actual, test.expected)
+			raw := Raw(test.input + ` `)
+			actual := raw.ValueTyped()
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** Raw.ValueTyped() = %#v, wanted %#v", actual, test.expected)
+			}
+			if string(raw) != ` ` {
+				t.Errorf("** Raw.ValueTyped() left %q, wanted the rest of input", raw)
 			}
 		})
 	}
+
+	ensurePanic(t, func() { raw(`[1.2.3]`).ValueTyped() }, `unexpected JSON: 1.2.3: strconv.ParseFloat: parsing "1.2.3": invalid syntax`)
 }
 
-func TestStr(t *testing.T) {
+func TestScalarPreserve(t *testing.T) {
 	tests := []struct {
-		name     string
 		token    Token
-		expected string
+		expected any
 	}{
-		{`string token`, Token(`"hello"`), "hello"},
-		{`escape double quote`, Token(`"\""`), `"`},
-		{`escape backslash`, Token(`"\\"`), `\`},
-		{`escape forward slash`, Token(`"\/"`), "/"},
-		{`escape backspace`, Token(`"\b"`), "\b"},
-		{`escape form feed`, Token(`"\f"`), "\f"},
-		{`escape newline`, Token(`"\n"`), "\n"},
-		{`escape carriage return`, Token(`"\r"`), "\r"},
-		{`escape tab`, Token(`"\t"`), "\t"},
-		{`multiple escapes`, Token(`"\n\t\f"`), "\n\t\f"},
-		{`escapes with other characters`, Token(`"foo\nbar\tboz\t\\fubar\ffizboz"`), "foo\nbar\tboz\t\\fubar\ffizboz"},
-		{`unicode escape`, Token(`"\u263A"`), "☺"},
-		{`true`, Token("true"), "true"},
-		{`false`, Token("false"), "false"},
-		{`null`, Token("null"), ""},
-		{`EOF`, Token(nil), ""},
-		{`int`, Token("42"), "42"},
-		{`float`, Token("3.141"), "3.141"},
+		{Token(`42`), int64(42)},
+		{Token(`-9223372036854775808`), int64(math.MinInt64)},
+		{Token(`9223372036854775808`), 9223372036854775808.0},
+		{Token(`1.5`), 1.5},
+		{Token(`1e2`), 100.0},
+		{Token(`1e400`), math.Inf(1)},
+		{Token(`-1e400`), math.Inf(-1)},
+		{Token(`"x"`), "x"},
+		{Token(`true`), true},
+		{Token(`null`), nil},
+		{Token(nil), nil},
 	}
 
 	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			actual := test.token.Str()
-			if actual != test.expected {
-				t.Errorf("** Token.String(%s) = %s, wanted %s", test.token, actual, test.expected)
+		t.Run(string(test.token), func(t *testing.T) {
+			if actual := test.token.ScalarPreserve(); actual != test.expected {
+				t.Errorf("** Token.ScalarPreserve(%s) = %#v, wanted %#v", test.token, actual, test.expected)
 			}
 		})
 	}
+
+	ensurePanic(t, func() { Token(`[`).ScalarPreserve() }, "unexpected JSON: [")
 }
-func TestInt64(t *testing.T) {
+
+func TestEmptyInput(t *testing.T) {
+	const eof = "unexpected end of JSON"
 	tests := []struct {
 		name     string
-		token    Token
-		expected int64
+		f        func(raw *Raw) any
+		expected any // panic message if a string
 	}{
-		{`positive number`, Token("123"), 123},
-		{`negative number`, Token("-456"), -456},
-		{`zero`, Token("0"), 0},
-		{`int64 max`, Token("9223372036854775807"), 9223372036854775807},
-		{`int64 min`, Token("-9223372036854775808"), -9223372036854775808},
+		{`Next`, func(raw *Raw) any { return raw.Next() }, Token(nil)},
+		{`Peek`, func(raw *Raw) any { return raw.Peek() }, EOF},
+		{`Null`, func(raw *Raw) any { return raw.Null() }, false},
+		{`AtEOF`, func(raw *Raw) any { return raw.AtEOF() }, true},
+		{`EnsureEOF`, func(raw *Raw) any { raw.EnsureEOF(); return nil }, nil},
+		{`Value`, func(raw *Raw) any { return raw.Value() }, eof},
+		{`ValueCanonical`, func(raw *Raw) any { return raw.ValueCanonical() }, eof},
+		{`ValueOrdered`, func(raw *Raw) any { return raw.ValueOrdered() }, eof},
+		{`ValueIter`, func(raw *Raw) any { return raw.ValueIter() }, eof},
+		{`ValueTyped`, func(raw *Raw) any { return raw.ValueTyped() }, eof},
+		{`Skip`, func(raw *Raw) any { raw.Skip(); return nil }, eof},
+		{`StartObject`, func(raw *Raw) any { return raw.StartObject() }, eof},
+		{`StartArray`, func(raw *Raw) any { raw.StartArray(); return nil }, eof},
+		{`Int`, func(raw *Raw) any { return raw.Int() }, eof},
+		{`Bool`, func(raw *Raw) any { return raw.Bool() }, eof},
+		{`Enum`, func(raw *Raw) any { return raw.Enum("a") }, eof + ", wanted one of: a"},
 	}
 
-	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			actual := test.token.Int64()
-			if actual != test.expected {
-				t.Errorf("** Token.Int64(%v) = %d, wanted %d", test.token, actual, test.expected)
-			}
-		})
+	for _, input := range []string{``, " \n\t"} {
+		for _, test := range tests {
+			t.Run(fmt.Sprintf("%s(%q)", test.name, input), func(t *testing.T) {
+				if msg, ok := test.expected.(string); ok {
+					ensurePanic(t, func() { test.f(raw(input)) }, msg)
+				} else if actual := test.f(raw(input)); !reflect.DeepEqual(actual, test.expected) {
+					t.Errorf("** returned %v, wanted %v", actual, test.expected)
+				}
+			})
+		}
 	}
+
+	ensurePanic(t, func() { raw(`{"a": `).Value() }, "unexpected end of JSON")
+	ensurePanic(t, func() { raw(`[{"b": `).ValueIter() }, "unexpected end of JSON")
 }
 
-func TestUint64(t *testing.T) {
+func TestNull(t *testing.T) {
 	tests := []struct {
 		name     string
-		token    Token
-		expected uint64
+		input    string
+		expected bool
+		orEOF    bool
 	}{
-		{`zero`, Token("0"), 0},
-		{`positive number`, Token("123"), 123},
-		{`max uint64`, Token("18446744073709551615"), 18446744073709551615},
+		{`eof`, ``, false, true},
+		{`whitespace`, ` `, false, true},
+		{`null`, `null`, true, true},
+		{`true`, `true`, false, false},
+		{`false`, `false`, false, false},
+		{`integer`, `123`, false, false},
+		{`float`, `3.14`, false, false},
+		{`string`, `"foo"`, false, false},
+		{`array`, `[1, "two", 3.0, true, null]`, false, false},
+		{`object`, `{"name":"John", "age":30, "city": null}`, false, false},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			actual := test.token.Uint64()
+			raw := Raw(test.input)
+			actual := raw.Null()
 			if actual != test.expected {
-				t.Errorf("** Token.Uint64(%v) = %d, wanted %d", test.token, actual, test.expected)
+				t.Errorf("** Raw.Null() = %v, wanted %v", actual, test.expected)
+			}
+			raw = Raw(test.input)
+			if actual := raw.NullOrEOF(); actual != test.orEOF {
+				t.Errorf("** Raw.NullOrEOF() = %v, wanted %v", actual, test.orEOF)
+			}
+			if test.orEOF && raw.Peek() != EOF {
+				t.Errorf("** Raw.NullOrEOF() didn't consume null")
+			}
+			d := Decoder{Raw: Raw(test.input)}
+			if actual := d.NullOrEOF(); actual != test.orEOF {
+				t.Errorf("** Decoder.NullOrEOF() = %v, wanted %v", actual, test.orEOF)
 			}
 		})
 	}
 }
 
-func TestFloat(t *testing.T) {
+func TestNullable(t *testing.T) {
+	type result struct {
+		v     any
+		valid bool
+	}
+	r := raw(`"x" null 42 null 2.5 null true null`)
+	d := &Decoder{Raw: *r}
+	for i, f := range []func() result{
+		func() result { v, ok := r.NullString(); return result{v, ok} },
+		func() result { v, ok := r.NullString(); return result{v, ok} },
+		func() result { v, ok := r.NullInt64(); return result{v, ok} },
+		func() result { v, ok := r.NullInt64(); return result{v, ok} },
+		func() result { v, ok := r.NullFloat64(); return result{v, ok} },
+		func() result { v, ok := r.NullFloat64(); return result{v, ok} },
+		func() result { v, ok := r.NullBool(); return result{v, ok} },
+		func() result { v, ok := r.NullBool(); return result{v, ok} },
+		func() result { v, ok := d.NullString(); return result{v, ok} },
+		func() result { v, ok := d.NullString(); return result{v, ok} },
+		func() result { v, ok := d.NullInt64(); return result{v, ok} },
+		func() result { v, ok := d.NullInt64(); return result{v, ok} },
+		func() result { v, ok := d.NullFloat64(); return result{v, ok} },
+		func() result { v, ok := d.NullFloat64(); return result{v, ok} },
+		func() result { v, ok := d.NullBool(); return result{v, ok} },
+		func() result { v, ok := d.NullBool(); return result{v, ok} },
+	} {
+		expected := []result{{"x", true}, {"", false}, {int64(42), true}, {int64(0), false}, {2.5, true}, {0.0, false}, {true, true}, {false, false}}[i%8]
+		if actual := f(); actual != expected {
+			t.Errorf("** call %d = %v, wanted %v", i, actual, expected)
+		}
+	}
+	ensurePanic(t, func() { raw(`"x"`).NullInt64() }, `unexpected JSON: "x"`)
+}
+
+func TestFork(t *testing.T) {
+	raw := Raw(`{"type": "b", "x": 1} [2]`)
+	probe := raw.Fork()
+	probe.StartObject()
+	if probe.Str() != "b" {
+		t.Errorf("** probe read the wrong type")
+	}
+	if string(raw) != `{"type": "b", "x": 1} [2]` {
+		t.Errorf("** probing advanced the original to %s", raw)
+	}
+	raw.Skip()
+	rest := raw.Fork()
+	if rest.Skip(); rest.Peek() != EOF || raw.Peek() != StartArray {
+		t.Errorf("** forks didn't advance independently: %q, %q", rest, raw)
+	}
+}
+
+func TestPeekThenNext(t *testing.T) {
 	tests := []struct {
-		name     string
-		token    Token
-		expected float64
+		input    string
+		expected Kind
 	}{
-		{`positive float`, Token("3.14"), 3.14},
-		{`negative float`, Token("-2.718"), -2.718},
-		{`zero`, Token("0.0"), 0.0},
-		{`scientific notation`, Token("6.022e23"), 6.022e23},
+		{``, EOF},
+		{" \r\n\t", EOF},
+		{` { `, StartObject},
+		{` } `, EndObject},
+		{` [ `, StartArray},
+		{` ] `, EndArray},
+		{` "s" `, String},
+		{` "\"" `, String},
+		{` 1.5 `, Number},
+		{` -1 `, Number},
+		{` true `, True},
+		{` false `, False},
+		{` null `, Null},
+		{` : `, Colon},
+		{` , `, Comma},
+		{"\n\t[1]\n", StartArray},
+		{`"a"x`, String},
 	}
 
 	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
-			actual := test.token.Float()
-			if actual != test.expected {
-				t.Errorf("** Token.Float64(%v) = %g, wanted %g", test.token, actual, test.expected)
+		t.Run(test.input, func(t *testing.T) {
+			raw := Raw(test.input)
+			if kind := raw.PeekKind(); kind != test.expected || string(raw) != test.input {
+				t.Errorf("** PeekKind() = %q, wanted %q, leaving %q", kind, test.expected, raw)
+			}
+			if kind := raw.Peek(); kind != test.expected {
+				t.Errorf("** Peek() = %q, wanted %q", kind, test.expected)
+			}
+			if trimmed := strings.TrimLeft(test.input, " \r\n\t"); string(raw) != trimmed {
+				t.Errorf("** Peek() left %q, wanted only whitespace dropped: %q", raw, trimmed)
+			}
+			if kind := raw.Peek(); kind != test.expected {
+				t.Errorf("** second Peek() = %q, wanted %q", kind, test.expected)
+			}
+			if tok := raw.Next(); tok.Kind() != test.expected {
+				t.Errorf("** Next() after Peek() = %q, wanted %q", tok, test.expected)
 			}
 		})
 	}
 }
 
-func TestBool(t *testing.T) {
+func TestPeekInvalid(t *testing.T) {
+	for _, input := range []string{`x`, ` @`, "\x00", `'a'`} {
+		ensurePanic(t, func() { raw := Raw(input); raw.Peek() }, "invalid JSON")
+		ensurePanic(t, func() { Raw(input).PeekKind() }, "invalid JSON")
+		ensurePanic(t, func() { raw := Raw(input); raw.Next() }, "invalid JSON")
+	}
+	ensurePanic(t, func() { raw := Raw(`truex`); raw.Next(); raw.EnsureEOF() }, "invalid JSON")
+	ensurePanic(t, func() { raw := Raw(`0A`); raw.Next(); raw.EnsureEOF() }, "invalid JSON")
+
+	raw := Raw(`tru`)
+	if kind := raw.Peek(); kind != True {
+		t.Errorf("** Peek() = %q, wanted %q", kind, True)
+	}
+	ensurePanic(t, func() { raw.Next() }, "invalid JSON")
+}
+
+func TestAtEOF(t *testing.T) {
 	tests := []struct {
 		name     string
-		token    Token
+		input    string
 		expected bool
 	}{
-		{`true`, Token("true"), true},
-		{`false`, Token("false"), false},
+		{`empty`, ``, true},
+		{`whitespace`, " \r\n\t", true},
+		{`byte order mark`, "\xEF\xBB\xBF ", false},
+		{`value`, `1`, false},
+		{`value after whitespace`, `  {}`, false},
+		{`invalid`, `x`, false},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			actual := test.token.Bool()
+			raw := Raw(test.input)
+			actual := raw.AtEOF()
 			if actual != test.expected {
-				t.Errorf("** Token.Bool(%v) = %t, wanted %t", test.token, actual, test.expected)
+				t.Errorf("** Raw.AtEOF(%q) = %v, wanted %v", test.input, actual, test.expected)
+			}
+			if string(raw) != test.input {
+				t.Errorf("** Raw.AtEOF(%q) consumed input", test.input)
 			}
 		})
 	}
 }
 
-func TestValue(t *testing.T) {
+func TestIsEmpty(t *testing.T) {
 	tests := []struct {
-		name     string
-		input    string
-		expected any
+		input  string
+		object bool
+		array  bool
 	}{
-		{`eof`, ``, nil},
-		{`null`, `null`, nil},
-		{`true`, `true`, true},
-		{`false`, `false`, false},
-		{`integer`, `123`, 123.0},
-		{`float`, `3.14`, 3.14},
-		{`scientific notation`, `6.022e23`, 6.022e23},
-		{`string`, `"Hello, World!"`, "Hello, World!"},
-		{`array`, `[1, "two", 3.0, true, null]`, []any{1.0, "two", 3.0, true, nil}},
-		{`object`, `{"name":"John", "age":30, "city":"New York"}`, map[string]any{"name": "John", "age": 30.0, "city": "New York"}},
-		{`nested object`, `{"person":{"name":"John", "age":30}, "city":"New York"}`, map[string]any{"person": map[string]any{"name": "John", "age": 30.0}, "city": "New York"}},
-		{`nested array`, `[1, [2, 3], 4]`, []any{1.0, []any{2.0, 3.0}, 4.0}},
+		{``, false, false},
+		{`{}`, true, false},
+		{" {\n\t} ", true, false},
+		{`{"a":1}`, false, false},
+		{`[]`, false, true},
+		{` [ ] ,`, false, true},
+		{`[1]`, false, false},
+		{`[{}]`, false, false},
+		{`{`, false, false},
+		{`null`, false, false},
+		{`"{}"`, false, false},
 	}
 
 	for _, test := range tests {
-		t.Run(test.name, func(t *testing.T) {
+		t.Run(test.input, func(t *testing.T) {
 			raw := Raw(test.input)
-			actual := raw.Value()
-			if !reflect.DeepEqual(actual, test.expected) {
-				t.Errorf("** Raw.Value() = %v, wanted %v", actual, test.expected)
+			if actual := raw.IsEmptyObject(); actual != test.object {
+				t.Errorf("** Raw.IsEmptyObject(%q) = %v, wanted %v", test.input, actual, test.object)
+			}
+			if actual := raw.IsEmptyArray(); actual != test.array {
+				t.Errorf("** Raw.IsEmptyArray(%q) = %v, wanted %v", test.input, actual, test.array)
+			}
+			if string(raw) != test.input {
+				t.Errorf("** consumed input %q", test.input)
 			}
 		})
 	}
 }
 
-func TestNull(t *testing.T) {
+func TestMore(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
-		expected bool
+		expected []any
 	}{
-		{`eof`, ``, false},
-		{`null`, `null`, true},
-		{`true`, `true`, false},
-		{`false`, `false`, false},
-		{`integer`, `123`, false},
-		{`float`, `3.14`, false},
-		{`string`, `"foo"`, false},
-		{`array`, `[1, "two", 3.0, true, null]`, false},
-		{`object`, `{"name":"John", "age":30, "city": null}`, false},
+		{`empty`, ``, nil},
+		{`whitespace`, " \n ", nil},
+		{`single`, `{"a":1}`, []any{map[string]any{"a": 1.0}}},
+		{`stream`, "1 \"two\"\n[3]\n{}\nnull\n", []any{1.0, "two", []any{3.0}, map[string]any{}, nil}},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			raw := Raw(test.input)
-			actual := raw.Null()
-			if actual != test.expected {
-				t.Errorf("** Raw.Null() = %v, wanted %v", actual, test.expected)
+			var actual []any
+			for raw.More() {
+				actual = append(actual, raw.Value())
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** values = %v, wanted %v", actual, test.expected)
 			}
 		})
 	}
@@ -249,12 +1671,34 @@ func TestPanics(t *testing.T) {
 		expected string
 	}{
 		{`bare word`, func() { raw(`xxx`).Next() }, "invalid JSON"},
-		{`unclosed string`, func() { raw(`"xxx`).Next() }, "invalid JSON"},
-		{`unterminated escape`, func() { raw(`"xxx\`).Next() }, "invalid JSON"},
+		{`truncated byte order mark`, func() { raw("\xEF\xBB1").Next() }, "invalid JSON"},
+		{`leading byte order mark`, func() { raw("\xEF\xBB\xBF{}").Next() }, "invalid JSON: unexpected byte order mark"},
+		{`byte order mark in array`, func() { raw("[1,\xEF\xBB\xBF2]").Value() }, "invalid JSON: unexpected byte order mark"},
+		{`byte order mark in object`, func() { raw("{\"a\":\xEF\xBB\xBF1}").Value() }, "invalid JSON: unexpected byte order mark"},
+		{`peeked byte order mark`, func() { raw("[\xEF\xBB\xBF]").Value() }, "invalid JSON: unexpected byte order mark"},
+		{`misspelled true`, func() { raw(`tru3`).Next() }, "invalid JSON"},
+		{`misspelled false`, func() { raw(`fakse`).Next() }, "invalid JSON"},
+		{`misspelled null`, func() { raw(`nil`).Next() }, "invalid JSON"},
+		{`truncated true`, func() { raw(`tr`).Next() }, "invalid JSON"},
+		{`truncated false`, func() { raw(`fals`).Next() }, "invalid JSON"},
+		{`truncated null`, func() { raw(`nul`).Next() }, "invalid JSON"},
+		{`truncated literal in array`, func() { raw(`[t]`).Value() }, "invalid JSON"},
+		{`unclosed string`, func() { raw(`"xxx`).Next() }, "invalid JSON: unterminated string"},
+		{`lone quote`, func() { raw(`"`).Next() }, "invalid JSON: unterminated string"},
+		{`unterminated escape`, func() { raw(`"xxx\`).Next() }, "invalid JSON: unterminated escape"},
+		{`lone escape`, func() { raw(`"\`).Next() }, "invalid JSON: unterminated escape"},
+		{`escaped closing quote`, func() { raw(`"a\"`).Next() }, "invalid JSON: unterminated string"},
 		{`unfinished unicode escape`, func() { raw(`"xxx\u12"`).Str() }, "invalid JSON"},
 		{`unfinished unicode escape in unquote`, func() { unquoteString([]byte(`"xxx\"`)) }, "invalid JSON"},
 		{`invalid unicode escape`, func() { raw(`"xxx\u123Z"`).Str() }, "invalid JSON"},
 
+		{`ScanToken invalid`, func() { ScanToken([]byte(`x`)) }, "invalid JSON"},
+		{`ScanString empty`, func() { ScanString(nil) }, "invalid JSON"},
+		{`ScanString non-string`, func() { ScanString([]byte(` "a"`)) }, "invalid JSON"},
+		{`ScanString unterminated`, func() { ScanString([]byte(`"a`)) }, "invalid JSON: unterminated string"},
+		{`ScanNumber empty`, func() { ScanNumber(nil) }, "invalid JSON"},
+		{`ScanNumber non-number`, func() { ScanNumber([]byte(`x1`)) }, "invalid JSON"},
+
 		{`array cannot Str`, func() { raw(`[]`).Str() }, "unexpected JSON: ["},
 		{`array cannot Int`, func() { raw(`[]`).Int() }, "unexpected JSON: ["},
 		{`array cannot Scalar`, func() { raw(`[]`).Next().Scalar() }, "unexpected JSON: ["},
@@ -262,21 +1706,70 @@ func TestPanics(t *testing.T) {
 		{`object cannot Str`, func() { raw(`{}`).Str() }, "unexpected JSON: {"},
 		{`comma cannot Str`, func() { raw(`,`).Str() }, "unexpected JSON: ,"},
 		{`comma cannot Value`, func() { raw(`,`).Value() }, "invalid JSON"},
+		{`comma cannot ValueCanonical`, func() { raw(`,`).ValueCanonical() }, "invalid JSON"},
+		{`duplicate key in ValueCanonical`, func() { raw(`{"a": 1, "\u0061": 2}`).ValueCanonical() }, `invalid JSON: duplicate key "\u0061"`},
+		{`comma cannot ValueOrdered`, func() { raw(`,`).ValueOrdered() }, "invalid JSON"},
+		{`unknown escape in strict StrAppend`, func() { Token(`"a\xb"`).StrAppend(nil, true) }, `invalid JSON: invalid escape sequence \x in string "a\xb"`},
+		{`short unicode escape in strict StrAppend`, func() { Token(`"\u12"`).StrAppend(nil, true) }, `invalid JSON: invalid escape sequence \u in string "\u12"`},
+		{`non-hex unicode escape in strict StrAppend`, func() { Token(`"\u12G4"`).StrAppend(nil, true) }, `invalid JSON: invalid escape sequence \u in string "\u12G4"`},
+		{`object cannot StrAppend`, func() { Token(`{`).StrAppend(nil, false) }, "unexpected JSON: {"},
+		{`token outside src`, func() { src := []byte(`1`); Token(bytes.Clone(src)).Offset(src) }, "tinyjson: token is not within src"},
+		{`token beyond src`, func() { src := []byte(`[1, 2]`); Token(src[1:]).Range(src[:3]) }, "tinyjson: token is not within src"},
+		{`EOF has no offset`, func() { Token(nil).Offset([]byte(`1`)) }, "tinyjson: token is not within src"},
+		{`unclosed container`, func() { raw(`[1, [2]`).SkipContainer() }, "unexpected end of JSON"},
+		{`comma cannot ValueIter`, func() { raw(`,`).ValueIter() }, "invalid JSON"},
+		{`unclosed nested ValueIter`, func() { raw(`[{"a": [1`).ValueIter() }, "unexpected end of JSON"},
 		{`comma cannot Skip`, func() { raw(`,`).Skip() }, "invalid JSON"},
 		{`comma cannot EnsureEOF`, func() { raw(`,`).EnsureEOF() }, "invalid JSON"},
 
+		{`unknown Enum`, func() { raw(`"Active"`).Enum("active", "deleted") }, `unexpected JSON: "Active", wanted one of: active, deleted`},
+		{`unknown EnumFold`, func() { raw(`"Actives"`).EnumFold("active", "deleted") }, `unexpected JSON: "Actives", wanted one of: active, deleted`},
+		{`number cannot EnumFold`, func() { raw(`1`).EnumFold("1") }, `unexpected JSON: 1, wanted one of: 1`},
+		{`number cannot Enum`, func() { raw(`1`).Enum("1") }, `unexpected JSON: 1, wanted one of: 1`},
+
+		{`double plus cannot Uint64`, func() { raw(`++5`).Uint64() }, `unexpected JSON: ++5`},
+		{`garbage number cannot Float`, func() { raw(`1-2+3`).Float() }, `unexpected JSON: 1-2+3`},
+
+		{`non-numeric string cannot FloatLenient`, func() { raw(`"abc"`).FloatLenient() }, `unexpected JSON: "abc"`},
+		{`empty string cannot FloatLenient`, func() { raw(`""`).FloatLenient() }, `unexpected JSON: ""`},
+		{`infinity string cannot FloatLenient`, func() { raw(`"Inf"`).FloatLenient() }, `unexpected JSON: "Inf"`},
+		{`garbage string cannot FloatLenient`, func() { raw(`"1x"`).FloatLenient() }, `unexpected JSON: "1x"`},
+		{`negative infinity string cannot FloatLenient`, func() { raw(`"-Inf"`).FloatLenient() }, `unexpected JSON: "-Inf"`},
+		{`positive infinity string cannot FloatLenient`, func() { raw(`"+Infinity"`).FloatLenient() }, `unexpected JSON: "+Infinity"`},
+		{`hex string cannot FloatLenient`, func() { raw(`"0x10"`).FloatLenient() }, `unexpected JSON: "0x10"`},
+		{`underscore string cannot FloatLenient`, func() { raw(`"1_0"`).FloatLenient() }, `unexpected JSON: "1_0"`},
+		{`incomplete exponent string cannot FloatLenient`, func() { raw(`"1e"`).FloatLenient() }, `unexpected JSON: "1e"`},
+		{`plus string cannot FloatLenient`, func() { raw(`"+5"`).FloatLenient() }, `unexpected JSON: "+5"`},
+		{`padded string cannot FloatLenient`, func() { raw(`" 5"`).FloatLenient() }, `unexpected JSON: " 5"`},
+		{`string cannot NumberCanonical`, func() { raw(`"1"`).NumberCanonical() }, `unexpected JSON: "1"`},
+		{`overflow cannot NumberCanonical`, func() { raw(`1e400`).NumberCanonical() }, `unexpected JSON: 1e400`},
+		{`bool cannot FloatLenient`, func() { raw(`true`).FloatLenient() }, `unexpected JSON: true`},
+
 		{`null cannot Int`, func() { raw(`null`).Int() }, "unexpected JSON: null"},
 		{`null cannot Float`, func() { raw(`null`).Float() }, "unexpected JSON: null"},
 		{`null cannot Bool`, func() { raw(`null`).Bool() }, "unexpected JSON: null"},
 
 		{`string cannot Int`, func() { raw(`"42"`).Int() }, `unexpected JSON: "42"`},
 		{`string cannot Int64`, func() { raw(`"42"`).Int64() }, `unexpected JSON: "42"`},
+		{`fraction cannot Int`, func() { raw(`1.5`).Int() }, `unexpected JSON: 1.5`},
+		{`fractional exponent cannot Int64`, func() { raw(`1.05e1`).Int64() }, `unexpected JSON: 1.05e1`},
+		{`overflowing exponent cannot Int64`, func() { raw(`1e19`).Int64() }, `unexpected JSON: 1e19`},
+		{`overflowing exponent cannot Int8`, func() { raw(`1.28e2`).Int8() }, `unexpected JSON: 1.28e2`},
 		{`string cannot Uint64`, func() { raw(`"42"`).Uint64() }, `unexpected JSON: "42"`},
+		{`fraction cannot Uint64`, func() { raw(`1.5`).Uint64() }, `unexpected JSON: 1.5`},
+		{`negative cannot Uint64`, func() { raw(`-1e0`).Uint64() }, `unexpected JSON: -1e0`},
+		{`overflow cannot Uint64`, func() { raw(`1.8446744073709551616e19`).Uint64() }, `unexpected JSON: 1.8446744073709551616e19`},
 		{`string cannot StartObject`, func() { raw(`"42"`).StartObject() }, `unexpected JSON: "42"`},
 		{`string cannot StartArray`, func() { raw(`"42"`).StartArray() }, `unexpected JSON: "42"`},
 
-		{`unclosed object`, func() { raw(`{"xxx": 42`).Value() }, "invalid JSON"},
-		{`unclosed array`, func() { raw(`["xxx"`).Value() }, "invalid JSON"},
+		{`unclosed object`, func() { raw(`{"xxx": 42`).Value() }, "unexpected end of JSON"},
+		{`unclosed array`, func() { raw(`["xxx"`).Value() }, "unexpected end of JSON"},
+		{`array truncated after comma`, func() { raw(`[1,`).Value() }, "unexpected end of JSON"},
+		{`object truncated after value`, func() { raw(`{"a":1`).Value() }, "unexpected end of JSON"},
+		{`object truncated after comma`, func() { raw(`{"a":1,`).Value() }, "unexpected end of JSON"},
+		{`object truncated after key`, func() { raw(`{"a"`).Value() }, "unexpected end of JSON"},
+		{`object truncated after brace`, func() { raw(`{`).Value() }, "unexpected end of JSON"},
+		{`skipped array truncated`, func() { raw(`[1,`).Skip() }, "unexpected end of JSON"},
 		{`no colon in object`, func() { raw(`{"a" 1}`).Value() }, "invalid JSON"},
 	}
 
@@ -287,6 +1780,246 @@ func TestPanics(t *testing.T) {
 	}
 }
 
+func TestTruncatedStrings(t *testing.T) {
+	inputs := []string{
+		`"hello"`,
+		`"a\\"`,
+		`"escaped\":\\\/\b\f\n\r\t\u263A"`,
+		`"\\\\\\"`,
+		`"\""`,
+	}
+	for _, input := range inputs {
+		for i := 1; i < len(input); i++ {
+			truncated := input[:i]
+			t.Run(truncated, func(t *testing.T) {
+				v := capturePanic(func() { raw(truncated).Next() })
+				if s, ok := v.(string); !ok || !strings.HasPrefix(s, "invalid JSON: unterminated ") {
+					t.Errorf("** Next(%s) paniced with: %v, wanted an unterminated string error", truncated, v)
+				}
+			})
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected any
+		err      string
+	}{
+		{`object`, `{"name":"John", "age":30}`, map[string]any{"name": "John", "age": 30.0}, ""},
+		{`surrounding whitespace`, " [1] \n", []any{1.0}, ""},
+		{`empty`, ``, nil, "unexpected end of JSON at offset 0"},
+		{`whitespace only`, `  `, nil, "unexpected end of JSON at offset 2"},
+		{`trailing data`, `1 2`, nil, "invalid JSON at offset 2"},
+		{`bad literal`, `[1, tru]`, nil, "invalid JSON at offset 4"},
+		{`bad number`, `[1, -]`, nil, "unexpected JSON: - at offset 5"},
+		{`unterminated string`, `{"a`, nil, "invalid JSON: unterminated string at offset 1"},
+		{`leading byte order mark`, "\xEF\xBB\xBF[1]", []any{1.0}, ""},
+		{`offset after byte order mark`, "\xEF\xBB\xBF[1, x]", nil, "invalid JSON at offset 7"},
+		{`byte order mark after whitespace`, " \xEF\xBB\xBF1", nil, "invalid JSON: unexpected byte order mark at offset 0"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := Parse([]byte(test.input))
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("** Parse(%s) = %v, %v, wanted error %s", test.input, actual, err, test.err)
+				}
+			} else if err != nil || !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** Parse(%s) = %v, %v, wanted %v", test.input, actual, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestTrimBOM(t *testing.T) {
+	if actual := string(TrimBOM([]byte("\xEF\xBB\xBF[1]"))); actual != "[1]" {
+		t.Errorf("** TrimBOM() = %q, wanted [1]", actual)
+	}
+	if actual := string(TrimBOM([]byte("\xEF\xBB"))); actual != "\xEF\xBB" {
+		t.Errorf("** TrimBOM() = %q, wanted the partial byte order mark kept", actual)
+	}
+
+	data := []byte("\xEF\xBB\xBF{\"a\": [1, 2], \"b\": \"x\"}")
+	if actual := ValueAll(data); !reflect.DeepEqual(actual, []any{map[string]any{"a": []any{1.0, 2.0}, "b": "x"}}) {
+		t.Errorf("** ValueAll() = %v", actual)
+	}
+	if actual := TokenCount(data); actual != 13 {
+		t.Errorf("** TokenCount() = %d, wanted 13", actual)
+	}
+	if actual := string(Filter(nil, data, func(path string) bool { return path == "b" })); actual != `{"b":"x"}` {
+		t.Errorf("** Filter() = %s", actual)
+	}
+	if actual := string(Redact(data, map[string]bool{"a": true})); actual != "\xEF\xBB\xBF{\"a\": \"***\", \"b\": \"x\"}" {
+		t.Errorf("** Redact() = %q, wanted the byte order mark copied verbatim", actual)
+	}
+	if actual, err := Canonicalize(data); string(actual) != `{"a":[1,2],"b":"x"}` || err != nil {
+		t.Errorf("** Canonicalize() = %s, %v", actual, err)
+	}
+	var v recordingVisitor
+	Visit(data, &v)
+	if len(v.events) == 0 {
+		t.Errorf("** Visit() reported no events")
+	}
+	if actual := DecodeSliceParallel([]byte("\xEF\xBB\xBF[1, 2]"), (*Raw).Int); !reflect.DeepEqual(actual, []int{1, 2}) {
+		t.Errorf("** DecodeSliceParallel() = %v", actual)
+	}
+	if !Equal(data, []byte(`{"b":"x","a":[1,2]}`)) {
+		t.Errorf("** Equal() = false with a byte order mark")
+	}
+}
+
+func TestRecoverSyntaxErrorRepanics(t *testing.T) {
+	failure := errors.New("bug")
+	parse := func() (err error) {
+		raw := Raw(`[1]`)
+		defer recoverSyntaxError(&err, nil, &raw)
+		panic(failure)
+	}
+	if e := capturePanic(func() { parse() }); e != failure {
+		t.Errorf("** panicked with %v, wanted %v", e, failure)
+	}
+}
+
+func TestValueAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []any
+	}{
+		{`mixed`, "{\"a\":1}\n[2, 3]\n\"x\" 4 true null{}", []any{map[string]any{"a": 1.0}, []any{2.0, 3.0}, "x", 4.0, true, nil, map[string]any{}}},
+		{`trailing whitespace`, " 1 \r\n\t ", []any{1.0}},
+		{`empty`, ``, nil},
+		{`whitespace only`, " \n ", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := ValueAll([]byte(test.input))
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("** ValueAll(%q) = %v, wanted %v", test.input, actual, test.expected)
+			}
+		})
+	}
+
+	ensurePanic(t, func() { ValueAll([]byte(`1 [2`)) }, "unexpected end of JSON")
+	ensurePanic(t, func() { ValueAll([]byte(`1 x`)) }, "invalid JSON")
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{`{"a": 1, "b": [true, null]}`, "{\"b\":[true,null],\n\"a\":1}", true},
+		{`1`, `1.0`, true},
+		{`[100]`, `[1e2]`, true},
+		{`-0`, `0`, true},
+		{`"\u0041"`, `"A"`, true},
+		{`{}`, `{ }`, true},
+		{`[[], {}]`, `[[],{}]`, true},
+		{`{"a": 1, "a": 2}`, `{"a": 2}`, true},
+		{`1`, `2`, false},
+		{`"1"`, `1`, false},
+		{`null`, `false`, false},
+		{`[1, 2]`, `[2, 1]`, false},
+		{`[1]`, `[1, 1]`, false},
+		{`[]`, `{}`, false},
+		{`{"a": 1}`, `{"b": 1}`, false},
+		{`{"a": 1}`, `{"a": 1, "b": 2}`, false},
+		{`{"a": [1]}`, `{"a": [2]}`, false},
+		{`{"a": 1}`, `[1]`, false},
+		{``, ``, false},
+		{`1`, `1 2`, false},
+		{`[1`, `[1`, false},
+		{`1e400`, `1e400`, true},
+		{`[-1e400]`, `[-0.0100e402]`, true},
+		{`1.5e400`, `15E+399`, true},
+		{`1e400`, `2e400`, false},
+		{`1e400`, `-1e400`, false},
+		{`1e400`, `1e401`, false},
+		{`1e99999999999999999999`, `1e99999999999999999999`, true},
+		{`1e99999999999999999999`, `10e99999999999999999998`, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.a+" vs "+test.b, func(t *testing.T) {
+			if actual := Equal([]byte(test.a), []byte(test.b)); actual != test.expected {
+				t.Errorf("** Equal(%s, %s) = %v, wanted %v", test.a, test.b, actual, test.expected)
+			}
+			if actual := Equal([]byte(test.b), []byte(test.a)); actual != test.expected {
+				t.Errorf("** Equal(%s, %s) = %v, wanted %v", test.b, test.a, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestDeepEqualJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     any
+		expected bool
+	}{
+		{`int and float`, 30, 30.0, true},
+		{`int and fraction`, 30, 30.5, false},
+		{`sized ints`, int8(-5), int64(-5), true},
+		{`signed and unsigned`, int32(7), uint16(7), true},
+		{`more sizes`, int16(3), uint32(3), true},
+		{`negative and unsigned`, -1, uint64(18446744073709551615), false},
+		{`min int64`, int64(-9223372036854775808), -9223372036854775808.0, true},
+		{`large uint64`, uint64(18446744073709551615), uint(18446744073709551615), true},
+		{`large ints beyond float precision`, int64(9007199254740993), int64(9007199254740992), false},
+		{`float32`, float32(0.5), 0.5, true},
+		{`unsigned and float`, uint8(3), 3.0, true},
+		{`number and string`, 1, "1", false},
+		{`float and string`, 1.0, "1", false},
+		{`strings`, "a", "a", true},
+		{`bools`, true, false, false},
+		{`nil`, nil, nil, true},
+		{`nil and zero`, nil, 0, false},
+		{`maps`, map[string]any{"a": 1, "b": []any{2.0}}, map[string]any{"b": []any{2}, "a": 1.0}, true},
+		{`maps with different keys`, map[string]any{"a": 1}, map[string]any{"b": 1}, false},
+		{`maps with different values`, map[string]any{"a": 1}, map[string]any{"a": 2}, false},
+		{`maps of different sizes`, map[string]any{"a": 1}, map[string]any{}, false},
+		{`map and slice`, map[string]any{}, []any{}, false},
+		{`slices`, []any{1, "x", nil}, []any{1.0, "x", nil}, true},
+		{`slices of different lengths`, []any{1}, []any{1, 1}, false},
+		{`slices with different values`, []any{1}, []any{2}, false},
+		{`ordered`, []KV{{"a", 1}, {"b", 2.0}}, []KV{{"a", 1.0}, {"b", 2}}, true},
+		{`ordered differently`, []KV{{"a", 1}, {"b", 2}}, []KV{{"b", 2}, {"a", 1}}, false},
+		{`ordered with different values`, []KV{{"a", 1}}, []KV{{"a", 2}}, false},
+		{`ordered of different lengths`, []KV{{"a", 1}}, []KV{}, false},
+		{`ordered and map`, []KV{}, map[string]any{}, false},
+		{`uncomparable values`, []int{1, 2}, []int{1, 2}, true},
+		{`different uncomparable values`, []int{1, 2}, []int{1, 3}, false},
+		{`uncomparable and comparable`, map[string]int{"a": 1}, "a", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := DeepEqualJSON(test.a, test.b); actual != test.expected {
+				t.Errorf("** DeepEqualJSON(%v, %v) = %v, wanted %v", test.a, test.b, actual, test.expected)
+			}
+			if actual := DeepEqualJSON(test.b, test.a); actual != test.expected {
+				t.Errorf("** DeepEqualJSON(%v, %v) = %v, wanted %v", test.b, test.a, actual, test.expected)
+			}
+		})
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add([]byte(`{"name":"test","bars":[{"title":"one","count":1},{"title":"two","count":2}]}`))
+	f.Add([]byte(`[1, -2.5e3, "a\u263A\n", true, false, null, {}]`))
+	f.Add([]byte(`"\`))
+	f.Add([]byte(`tru`))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		Parse(data)
+	})
+}
+
 func BenchmarkDecode(t *testing.B) {
 	orig := Raw(`{"title":"one","count":1}`)
 	for i := 0; i < t.N; i++ {
@@ -297,6 +2030,28 @@ func BenchmarkDecode(t *testing.B) {
 	}
 }
 
+var benchmarkValueInput = Raw(`{"id":12345,"name":"Widget","tags":["a","b","c"],"price":9.99,"stock":{"warehouse":12,"store":3},"active":true}`)
+
+func BenchmarkValue(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		raw := benchmarkValueInput
+		raw.Value()
+	}
+}
+
+func BenchmarkValueIter(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		raw := benchmarkValueInput
+		raw.ValueIter()
+	}
+}
+
+func fnv64a(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
 func raw(data string) *Raw {
 	raw := Raw(data)
 	return &raw
@@ -0,0 +1,87 @@
+package tinyjson
+
+// Visitor receives the parts of a JSON document as Visit encounters them,
+// for processing documents of any size without building a tree.
+type Visitor interface {
+	OnStartObject()
+	OnKey(key Token) // followed by the events of the value
+	OnEndObject()
+	OnStartArray()
+	OnEndArray()
+	OnString(t Token)
+	OnNumber(t Token)
+	OnBool(t Token)
+	OnNull()
+}
+
+// DiscardVisitor ignores all events, for checking that a document parses
+// without building anything and without allocating:
+//
+//	tinyjson.Visit(data, tinyjson.DiscardVisitor{})
+//
+// Like Raw, Visit is lenient; visit with a Decoder with Strict set to reject
+// everything RFC 8259 doesn't allow.
+type DiscardVisitor struct{}
+
+func (DiscardVisitor) OnStartObject()   {}
+func (DiscardVisitor) OnKey(key Token)  {}
+func (DiscardVisitor) OnEndObject()     {}
+func (DiscardVisitor) OnStartArray()    {}
+func (DiscardVisitor) OnEndArray()      {}
+func (DiscardVisitor) OnString(t Token) {}
+func (DiscardVisitor) OnNumber(t Token) {}
+func (DiscardVisitor) OnBool(t Token)   {}
+func (DiscardVisitor) OnNull()          {}
+
+// Visit parses a single JSON document, reporting its parts to v in order.
+// Like Raw, it panics on invalid JSON, including empty input, possibly after
+// some events have been delivered.
+func Visit(data []byte, v Visitor) {
+	raw := Raw(TrimBOM(data))
+	if raw.Peek() == EOF {
+		panic("unexpected end of JSON")
+	}
+	raw.Visit(v)
+	raw.EnsureEOF()
+}
+
+// Visit consumes the next JSON value, reporting its parts to v in order.
+// Does nothing at EOF.
+func (raw *Raw) Visit(v Visitor) {
+	raw.visit(nil, v)
+}
+
+func (raw *Raw) visit(d *Decoder, v Visitor) {
+	t := raw.next(d)
+	switch t.Kind() {
+	case EOF:
+		break
+	case StartObject:
+		d.enter()
+		defer d.leave()
+		v.OnStartObject()
+		for key := raw.continueObject(d); key != nil; key = raw.continueObject(d) {
+			v.OnKey(key)
+			raw.visit(d, v)
+		}
+		v.OnEndObject()
+	case StartArray:
+		d.enter()
+		defer d.leave()
+		v.OnStartArray()
+		for raw.continueArray(d) {
+			raw.visit(d, v)
+		}
+		v.OnEndArray()
+	case String:
+		v.OnString(t)
+	case Number:
+		v.OnNumber(t)
+	case True, False:
+		v.OnBool(t)
+	case Null:
+		v.OnNull()
+	default:
+		panic("invalid JSON")
+	}
+}
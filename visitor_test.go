@@ -0,0 +1,101 @@
+package tinyjson
+
+import (
+	"strings"
+	"testing"
+)
+
+type recordingVisitor struct {
+	events []string
+}
+
+func (r *recordingVisitor) OnStartObject()   { r.events = append(r.events, "{") }
+func (r *recordingVisitor) OnKey(key Token)  { r.events = append(r.events, "key:"+key.Str()) }
+func (r *recordingVisitor) OnEndObject()     { r.events = append(r.events, "}") }
+func (r *recordingVisitor) OnStartArray()    { r.events = append(r.events, "[") }
+func (r *recordingVisitor) OnEndArray()      { r.events = append(r.events, "]") }
+func (r *recordingVisitor) OnString(t Token) { r.events = append(r.events, "str:"+t.Str()) }
+func (r *recordingVisitor) OnNumber(t Token) { r.events = append(r.events, "num:"+t.Raw()) }
+func (r *recordingVisitor) OnBool(t Token)   { r.events = append(r.events, "bool:"+t.Raw()) }
+func (r *recordingVisitor) OnNull()          { r.events = append(r.events, "null") }
+
+func TestVisit(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{`scalar`, `1.5`, `num:1.5`},
+		{`document`, `{"a": [1, "x\n", true, false, null], "b": {}, "c": []}`, "{ key:a [ num:1 str:x\n bool:true bool:false null ] key:b { } key:c [ ] }"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var v recordingVisitor
+			Visit([]byte(test.input), &v)
+			if actual := strings.Join(v.events, " "); actual != test.expected {
+				t.Errorf("** Visit(%s) reported %q, wanted %q", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestVisitStream(t *testing.T) {
+	var v recordingVisitor
+	raw := Raw(`1 [2]`)
+	for raw.More() {
+		raw.Visit(&v)
+	}
+	if actual, expected := strings.Join(v.events, " "), "num:1 [ num:2 ]"; actual != expected {
+		t.Errorf("** Raw.Visit reported %q, wanted %q", actual, expected)
+	}
+	raw.Visit(&v)
+	if len(v.events) != 4 {
+		t.Errorf("** Raw.Visit at EOF reported %q", v.events[4:])
+	}
+}
+
+func TestVisitPanics(t *testing.T) {
+	ensurePanic(t, func() { Visit([]byte(`[1, x]`), &recordingVisitor{}) }, "invalid JSON")
+	ensurePanic(t, func() { Visit([]byte(`[1],`), &recordingVisitor{}) }, "invalid JSON")
+	ensurePanic(t, func() { Visit([]byte(`}`), &recordingVisitor{}) }, "invalid JSON")
+	ensurePanic(t, func() { Visit(nil, &recordingVisitor{}) }, "unexpected end of JSON")
+	ensurePanic(t, func() { Visit([]byte(" \n"), &recordingVisitor{}) }, "unexpected end of JSON")
+}
+
+func TestDecoderVisit(t *testing.T) {
+	var v recordingVisitor
+	d := Decoder{Raw: Raw(`{"a": [true]}`), MaxDepth: 2}
+	d.Visit(&v)
+	if actual, expected := strings.Join(v.events, " "), "{ key:a [ bool:true ] }"; actual != expected {
+		t.Errorf("** Decoder.Visit reported %q, wanted %q", actual, expected)
+	}
+
+	d = Decoder{Raw: Raw(`[[[]]]`), MaxDepth: 2}
+	ensurePanic(t, func() { d.Visit(&recordingVisitor{}) }, "invalid JSON: nesting exceeds maximum depth")
+	d = Decoder{Raw: Raw(`[1 2]`), Strict: true}
+	ensurePanic(t, func() { d.Visit(&recordingVisitor{}) }, "invalid JSON: missing comma")
+}
+
+func TestDiscardVisitor(t *testing.T) {
+	data := []byte(`{"a": [1, "x\n", true, false, null], "b": {}, "c": [[]]}`)
+	if allocs := testing.AllocsPerRun(10, func() { Visit(data, DiscardVisitor{}) }); allocs != 0 {
+		t.Errorf("** Visit with DiscardVisitor made %v allocations, wanted 0", allocs)
+	}
+	ensurePanic(t, func() { Visit([]byte(`{"a": [1}`), DiscardVisitor{}) }, "invalid JSON")
+	d := Decoder{Raw: Raw(`[1 2]`), Strict: true}
+	ensurePanic(t, func() { d.Visit(DiscardVisitor{}) }, "invalid JSON: missing comma")
+}
+
+func BenchmarkDiscardVisitor(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 100; i++ {
+		sb.WriteString(`{"id": 123, "name": "item", "tags": ["a", "b"], "ok": true, "x": null}`)
+	}
+	data := []byte("[" + strings.ReplaceAll(sb.String(), "}{", "},{") + "]")
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Visit(data, DiscardVisitor{})
+	}
+}
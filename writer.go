@@ -0,0 +1,326 @@
+package tinyjson
+
+import (
+	"io"
+	"sort"
+	"strconv"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// AppendEscape appends s to dst as a quoted JSON string literal, escaping
+// quotes, backslashes and control characters; it is the inverse of Token.Str.
+// Bytes that aren't valid UTF-8 are copied as is.
+func AppendEscape(dst []byte, s string) []byte {
+	return appendEscape(dst, s, false)
+}
+
+// AppendEscapeHTML is like AppendEscape, but also escapes <, > and & as
+// \u003c, \u003e and \u0026, and U+2028 and U+2029 as \u2028 and \u2029, so
+// that the result can be safely embedded into HTML <script> tags, like
+// encoding/json does by default.
+func AppendEscapeHTML(dst []byte, s string) []byte {
+	return appendEscape(dst, s, true)
+}
+
+func appendEscape(dst []byte, s string, html bool) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 0x20 && c != '"' && c != '\\' && (!html || (c != '<' && c != '>' && c != '&' && c != 0xE2)) {
+			continue
+		}
+		if c == 0xE2 && (i+2 >= len(s) || s[i+1] != 0x80 || (s[i+2] != 0xA8 && s[i+2] != 0xA9)) {
+			continue
+		}
+		dst = append(dst, s[start:i]...)
+		switch c {
+		case '"', '\\':
+			dst = append(dst, '\\', c)
+		case '\b':
+			dst = append(dst, '\\', 'b')
+		case '\f':
+			dst = append(dst, '\\', 'f')
+		case '\n':
+			dst = append(dst, '\\', 'n')
+		case '\r':
+			dst = append(dst, '\\', 'r')
+		case '\t':
+			dst = append(dst, '\\', 't')
+		case 0xE2:
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[s[i+2]&0xF])
+			i += 2
+		default:
+			dst = append(dst, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xF])
+		}
+		start = i + 1
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// AppendValue appends the JSON encoding of v, which must be one of the types
+// returned by Value and ValueOrdered: nil, bool, float64, string, []any,
+// map[string]any or []KV. Map keys are written in sorted order, and numbers
+// in the shortest form that round-trips, like JavaScript's Number.toString
+// does, so the output is deterministic. Panics on other types and on
+// infinities and NaNs.
+func AppendValue(dst []byte, v any) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(dst, "null"...)
+	case bool:
+		if v {
+			return append(dst, "true"...)
+		}
+		return append(dst, "false"...)
+	case float64:
+		return appendFloat(dst, v)
+	case string:
+		return AppendEscape(dst, v)
+	case []any:
+		dst = append(dst, '[')
+		for i, item := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = AppendValue(dst, item)
+		}
+		return append(dst, ']')
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		dst = append(dst, '{')
+		for i, k := range keys {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = AppendEscape(dst, k)
+			dst = append(dst, ':')
+			dst = AppendValue(dst, v[k])
+		}
+		return append(dst, '}')
+	case []KV:
+		dst = append(dst, '{')
+		for i, kv := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = AppendEscape(dst, kv.Key)
+			dst = append(dst, ':')
+			dst = AppendValue(dst, kv.Value)
+		}
+		return append(dst, '}')
+	default:
+		panic("unsupported value type")
+	}
+}
+
+// Canonicalize re-encodes a JSON document in the canonical form defined by
+// RFC 8785 (JSON Canonicalization Scheme), suitable for hashing and signing:
+// without whitespace, with object keys sorted, with numbers formatted like
+// AppendValue does, and with only the required characters escaped in strings.
+// Invalid JSON, including duplicate object keys, is reported as a *SyntaxError.
+//
+// Unlike RFC 8785, keys are sorted by their UTF-8 bytes rather than UTF-16 code
+// units; the orders only differ for keys that mix characters above U+FFFF
+// with characters in the range U+E000 to U+FFFF.
+func Canonicalize(src []byte) (dst []byte, err error) {
+	raw := Raw(TrimBOM(src))
+	defer recoverSyntaxError(&err, src, &raw)
+	if raw.Peek() == EOF {
+		panic("unexpected end of JSON")
+	}
+	v := raw.ValueCanonical()
+	raw.EnsureEOF()
+	return AppendValue(nil, v), nil
+}
+
+// appendFloat formats f like JavaScript and encoding/json do: without an
+// exponent for magnitudes in [1e-6, 1e21), with the shortest exponent otherwise.
+func appendFloat(dst []byte, f float64) []byte {
+	if f != f || f > maxFloat64 || f < -maxFloat64 {
+		panic("unsupported value: " + strconv.FormatFloat(f, 'g', -1, 64))
+	}
+	if f == 0 {
+		return append(dst, '0') // including -0
+	}
+	abs := f
+	if abs < 0 {
+		abs = -abs
+	}
+	format := byte('f')
+	if abs < 1e-6 || abs >= 1e21 {
+		format = 'e'
+	}
+	dst = strconv.AppendFloat(dst, f, format, -1, 64)
+	if format == 'e' {
+		// clean up e-09 to e-9
+		if n := len(dst); n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+	return dst
+}
+
+const maxFloat64 = 0x1p1023 * (1 + (1 - 0x1p-52))
+
+// Writer builds a JSON document from a sequence of calls, inserting commas
+// and colons where needed, and panicking on calls that would produce invalid
+// JSON, like Key inside an array or a second top-level value. The zero value
+// is ready to use:
+//
+//	var w tinyjson.Writer
+//	w.BeginObject()
+//	w.Key("name")
+//	w.String("John")
+//	w.Key("tags")
+//	w.BeginArray()
+//	w.String("a")
+//	w.EndArray()
+//	w.EndObject()
+//	data := w.Bytes() // {"name":"John","tags":["a"]}
+type Writer struct {
+	buf   []byte
+	stack []Kind // StartObject or StartArray for each open container
+	count bool   // whether the innermost container, or the document, has a value
+	key   bool   // whether a key has been written, awaiting its value
+
+	out  io.Writer // for a stream writer, where buf is flushed to
+	size int       // for a stream writer, the buf length that triggers a flush
+	err  error     // the first error from out
+}
+
+// NewStreamWriter returns a Writer that writes the document to out, flushing
+// its buffer whenever it holds at least bufSize bytes, to keep memory bounded
+// when emitting large documents. Call Close once the document is complete to
+// flush the rest; write errors are reported by Flush and Close.
+func NewStreamWriter(out io.Writer, bufSize int) *Writer {
+	return &Writer{buf: make([]byte, 0, bufSize), out: out, size: bufSize}
+}
+
+// Flush writes any buffered data of a stream writer to its io.Writer, and
+// returns the first write error, after which the data is discarded. It does
+// nothing for a Writer that is not a stream writer.
+func (w *Writer) Flush() error {
+	if w.out != nil {
+		if w.err == nil && len(w.buf) > 0 {
+			_, w.err = w.out.Write(w.buf)
+		}
+		w.buf = w.buf[:0]
+	}
+	return w.err
+}
+
+// Close flushes a stream writer, like Flush, panicking if the document is
+// incomplete and no write error occurred.
+func (w *Writer) Close() error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if len(w.stack) > 0 || !w.count {
+		panic("tinyjson: Writer: incomplete JSON")
+	}
+	return nil
+}
+
+// Bytes returns the document written so far, panicking unless it is complete.
+// The result aliases the Writer's buffer until Reset. Stream writers don't
+// support Bytes.
+func (w *Writer) Bytes() []byte {
+	if w.out != nil {
+		panic("tinyjson: Writer: Bytes on a stream writer")
+	}
+	if len(w.stack) > 0 || !w.count {
+		panic("tinyjson: Writer: incomplete JSON")
+	}
+	return w.buf
+}
+
+// Reset discards the written data, keeping the buffer for reuse.
+func (w *Writer) Reset() {
+	w.buf, w.stack, w.count, w.key = w.buf[:0], w.stack[:0], false, false
+}
+
+// Key writes an object key; the next call must write its value.
+func (w *Writer) Key(k string) {
+	if n := len(w.stack); n == 0 || w.stack[n-1] != StartObject {
+		panic("tinyjson: Writer: Key outside of an object")
+	}
+	if w.key {
+		panic("tinyjson: Writer: Key where a value is expected")
+	}
+	w.spill()
+	if w.count {
+		w.buf = append(w.buf, ',')
+	}
+	w.buf = append(AppendEscape(w.buf, k), ':')
+	w.key = true
+}
+
+func (w *Writer) BeginObject() { w.value("BeginObject", '{'); w.open(StartObject) } // BeginObject writes {, to be followed by keys and values
+func (w *Writer) BeginArray()  { w.value("BeginArray", '['); w.open(StartArray) }   // BeginArray writes [, to be followed by values
+func (w *Writer) EndObject()   { w.close(StartObject, "EndObject", '}') }           // EndObject writes }, closing the innermost object
+func (w *Writer) EndArray()    { w.close(StartArray, "EndArray", ']') }             // EndArray writes ], closing the innermost array
+
+func (w *Writer) Null()               { w.value("Null"); w.buf = append(w.buf, "null"...) }              // Null writes null
+func (w *Writer) Bool(v bool)         { w.value("Bool"); w.buf = strconv.AppendBool(w.buf, v) }          // Bool writes true or false
+func (w *Writer) Int(v int)           { w.value("Int"); w.buf = strconv.AppendInt(w.buf, int64(v), 10) } // Int writes an integer
+func (w *Writer) Int64(v int64)       { w.value("Int64"); w.buf = strconv.AppendInt(w.buf, v, 10) }      // Int64 writes an integer
+func (w *Writer) Uint64(v uint64)     { w.value("Uint64"); w.buf = strconv.AppendUint(w.buf, v, 10) }    // Uint64 writes an integer
+func (w *Writer) Float(v float64)     { w.value("Float"); w.buf = appendFloat(w.buf, v) }                // Float writes a number like AppendValue, panics on infinities and NaNs
+func (w *Writer) String(v string)     { w.value("String"); w.buf = AppendEscape(w.buf, v) }              // String writes a quoted string
+func (w *Writer) StringHTML(v string) { w.value("StringHTML"); w.buf = AppendEscapeHTML(w.buf, v) }      // StringHTML writes a quoted string like AppendEscapeHTML
+func (w *Writer) Value(v any)         { w.value("Value"); w.buf = AppendValue(w.buf, v) }                // Value writes v like AppendValue
+func (w *Writer) Raw(v []byte)        { w.value("Raw", v...) }                                           // Raw writes v, which must be a single valid JSON value, verbatim
+
+// value checks that a value may be written now, writing a separator and the
+// given bytes if so.
+func (w *Writer) value(method string, b ...byte) {
+	if n := len(w.stack); n == 0 {
+		if w.count {
+			panic("tinyjson: Writer: " + method + " after the top-level value")
+		}
+	} else if w.stack[n-1] == StartObject {
+		if !w.key {
+			panic("tinyjson: Writer: " + method + " where a key is expected")
+		}
+		w.key = false
+	} else if w.count {
+		w.buf = append(w.buf, ',')
+	}
+	w.spill()
+	w.count = true
+	w.buf = append(w.buf, b...)
+}
+
+func (w *Writer) open(kind Kind) {
+	w.stack = append(w.stack, kind)
+	w.count = false
+}
+
+func (w *Writer) close(kind Kind, method string, b byte) {
+	n := len(w.stack)
+	if n == 0 || w.stack[n-1] != kind {
+		panic("tinyjson: Writer: " + method + " without a matching Begin")
+	}
+	if w.key {
+		panic("tinyjson: Writer: " + method + " where a value is expected")
+	}
+	w.spill()
+	w.stack = w.stack[:n-1]
+	w.count = true
+	w.buf = append(w.buf, b)
+}
+
+// spill flushes a stream writer once its buffer reaches the threshold.
+func (w *Writer) spill() {
+	if w.out != nil && len(w.buf) >= w.size {
+		w.Flush()
+	}
+}
@@ -0,0 +1,326 @@
+package tinyjson
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestAppendEscape(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{`empty`, "", `""`},
+		{`plain`, "hello", `"hello"`},
+		{`quote and backslash`, `say "a\b"`, `"say \"a\\b\""`},
+		{`short escapes`, "\b\f\n\r\t", `"\b\f\n\r\t"`},
+		{`other control characters`, "\x00\x1f\x7f", `"\u0000\u001f` + "\x7f" + `"`},
+		{`unicode`, "☺ ok", `"☺ ok"`},
+		{`html is not escaped`, "<a&b>", `"<a&b>"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := string(AppendEscape([]byte("x:"), test.input))
+			if actual != "x:"+test.expected {
+				t.Errorf("** AppendEscape(%q) = %s, wanted x:%s", test.input, actual, test.expected)
+			}
+			if back := Token(actual[2:]).Str(); back != test.input {
+				t.Errorf("** AppendEscape(%q) decodes back as %q", test.input, back)
+			}
+		})
+	}
+}
+
+func TestAppendEscapeHTML(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{`plain`, "hello", `"hello"`},
+		{`html`, "</script><a&b>", `"\u003c/script\u003e\u003ca\u0026b\u003e"`},
+		{`line and paragraph separators`, "a\u2028b\u2029c", `"a\u2028b\u2029c"`},
+		{`other three-byte characters`, "\u2027\u202a☺", "\"\u2027\u202a☺\""},
+		{`truncated separator`, "\xE2\x80", "\"\xE2\x80\""},
+		{`still escapes the usual`, "\"\n\x01", `"\"\n\u0001"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := string(AppendEscapeHTML(nil, test.input))
+			if actual != test.expected {
+				t.Errorf("** AppendEscapeHTML(%q) = %s, wanted %s", test.input, actual, test.expected)
+			}
+			if back := Token(actual).Str(); back != test.input {
+				t.Errorf("** AppendEscapeHTML(%q) decodes back as %q", test.input, back)
+			}
+		})
+	}
+}
+
+func TestAppendValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    any
+		expected string
+	}{
+		{`null`, nil, `null`},
+		{`true`, true, `true`},
+		{`false`, false, `false`},
+		{`string`, "a\"b", `"a\"b"`},
+		{`integer`, 42.0, `42`},
+		{`negative zero`, math.Copysign(0, -1), `0`},
+		{`fraction`, -0.5, `-0.5`},
+		{`large integer`, 1e20, `100000000000000000000`},
+		{`huge`, 1e21, `1e+21`},
+		{`tiny`, 1.5e-7, `1.5e-7`},
+		{`small`, 1e-6, `0.000001`},
+		{`shortest round trip`, 1.0 / 3, `0.3333333333333333`},
+		{`array`, []any{1.0, "x", nil, []any{}}, `[1,"x",null,[]]`},
+		{`nil array`, []any(nil), `[]`},
+		{`map with sorted keys`, map[string]any{"b": 1.0, "a": map[string]any{"d": true, "c": false}, "": nil}, `{"":null,"a":{"c":false,"d":true},"b":1}`},
+		{`ordered object`, []KV{{"b", 1.0}, {"a", []KV{}}}, `{"b":1,"a":{}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual := string(AppendValue(nil, test.input))
+			if actual != test.expected {
+				t.Errorf("** AppendValue(%v) = %s, wanted %s", test.input, actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestAppendValuePanics(t *testing.T) {
+	ensurePanic(t, func() { AppendValue(nil, 42) }, "unsupported value type")
+	ensurePanic(t, func() { AppendValue(nil, math.NaN()) }, "unsupported value: NaN")
+	ensurePanic(t, func() { AppendValue(nil, math.Inf(1)) }, "unsupported value: +Inf")
+	ensurePanic(t, func() { AppendValue(nil, math.Inf(-1)) }, "unsupported value: -Inf")
+}
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		err      string
+	}{
+		{`rfc 8785 example`, `{
+			"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+			"string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+			"literals": [null, true, false]
+		}`, `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`, ""},
+		{`nested sorting`, `{"b": {"d": 1, "c": 2}, "a": []}`, `{"a":[],"b":{"c":2,"d":1}}`, ""},
+		{`empty`, ` `, ``, "unexpected end of JSON at offset 1"},
+		{`duplicate keys`, `{"a": 1, "a": 2}`, ``, `invalid JSON: duplicate key "a" at offset 13`},
+		{`trailing data`, `{} {}`, ``, `invalid JSON at offset 3`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, err := Canonicalize([]byte(test.input))
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("** Canonicalize(%s) = %s, %v, wanted error %s", test.input, actual, err, test.err)
+				}
+			} else if err != nil || string(actual) != test.expected {
+				t.Errorf("** Canonicalize(%s) = %s, %v, wanted %s", test.input, actual, err, test.expected)
+			}
+		})
+	}
+}
+
+func TestWriter(t *testing.T) {
+	var w Writer
+	w.BeginObject()
+	w.Key("s")
+	w.String("a\"b")
+	w.Key("h")
+	w.StringHTML("<b>")
+	w.Key("nums")
+	w.BeginArray()
+	w.Int(-1)
+	w.Int64(1 << 40)
+	w.Uint64(1 << 63)
+	w.Float(2.5)
+	w.EndArray()
+	w.Key("flags")
+	w.BeginArray()
+	w.Bool(true)
+	w.Bool(false)
+	w.Null()
+	w.EndArray()
+	w.Key("empty")
+	w.BeginObject()
+	w.EndObject()
+	w.Key("nested")
+	w.BeginArray()
+	w.BeginArray()
+	w.EndArray()
+	w.BeginObject()
+	w.Key("v")
+	w.Value(map[string]any{"b": 1.0, "a": []any{nil}})
+	w.EndObject()
+	w.EndArray()
+	w.EndObject()
+
+	expected := `{"s":"a\"b","h":"\u003cb\u003e","nums":[-1,1099511627776,9223372036854775808,2.5],"flags":[true,false,null],"empty":{},"nested":[[],{"v":{"a":[null],"b":1}}]}`
+	if actual := string(w.Bytes()); actual != expected {
+		t.Errorf("** Writer produced %s, wanted %s", actual, expected)
+	}
+	if !Equal(w.Bytes(), []byte(expected)) {
+		t.Errorf("** Writer output doesn't parse back")
+	}
+
+	w.Reset()
+	w.String("x")
+	if actual := string(w.Bytes()); actual != `"x"` {
+		t.Errorf("** Writer after Reset produced %s, wanted \"x\"", actual)
+	}
+}
+
+func TestWriterMisuse(t *testing.T) {
+	tests := []struct {
+		name     string
+		f        func(w *Writer)
+		expected string
+	}{
+		{`Key at top level`, func(w *Writer) { w.Key("a") }, "tinyjson: Writer: Key outside of an object"},
+		{`Key in array`, func(w *Writer) { w.BeginArray(); w.Key("a") }, "tinyjson: Writer: Key outside of an object"},
+		{`Key after Key`, func(w *Writer) { w.BeginObject(); w.Key("a"); w.Key("b") }, "tinyjson: Writer: Key where a value is expected"},
+		{`value without Key`, func(w *Writer) { w.BeginObject(); w.String("a") }, "tinyjson: Writer: String where a key is expected"},
+		{`second value without Key`, func(w *Writer) { w.BeginObject(); w.Key("a"); w.Int(1); w.Int(2) }, "tinyjson: Writer: Int where a key is expected"},
+		{`object without Key`, func(w *Writer) { w.BeginObject(); w.BeginObject() }, "tinyjson: Writer: BeginObject where a key is expected"},
+		{`second top-level value`, func(w *Writer) { w.Null(); w.Bool(true) }, "tinyjson: Writer: Bool after the top-level value"},
+		{`second top-level container`, func(w *Writer) { w.BeginArray(); w.EndArray(); w.BeginArray() }, "tinyjson: Writer: BeginArray after the top-level value"},
+		{`EndObject at top level`, func(w *Writer) { w.EndObject() }, "tinyjson: Writer: EndObject without a matching Begin"},
+		{`EndArray closing object`, func(w *Writer) { w.BeginObject(); w.EndArray() }, "tinyjson: Writer: EndArray without a matching Begin"},
+		{`EndObject closing array`, func(w *Writer) { w.BeginArray(); w.EndObject() }, "tinyjson: Writer: EndObject without a matching Begin"},
+		{`EndObject after Key`, func(w *Writer) { w.BeginObject(); w.Key("a"); w.EndObject() }, "tinyjson: Writer: EndObject where a value is expected"},
+		{`Bytes when empty`, func(w *Writer) { w.Bytes() }, "tinyjson: Writer: incomplete JSON"},
+		{`Bytes when open`, func(w *Writer) { w.BeginArray(); w.Int(1); w.Bytes() }, "tinyjson: Writer: incomplete JSON"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var w Writer
+			ensurePanic(t, func() { test.f(&w) }, test.expected)
+		})
+	}
+}
+
+func TestWriterRaw(t *testing.T) {
+	input := raw(`{"keep": {"x": [1, 2]}, "drop": 3}`)
+	var w Writer
+	w.BeginArray()
+	w.Raw([]byte(`{"a":1}`))
+	w.Raw([]byte(`[true]`))
+	w.BeginObject()
+	for key := input.StartObject(); key != nil; key = input.ContinueObject() {
+		w.Key(key.Str())
+		input.Peek()
+		start := *input
+		w.Raw(start[:input.SkipN()])
+	}
+	w.EndObject()
+	w.Raw([]byte(`"s"`))
+	w.EndArray()
+
+	expected := `[{"a":1},[true],{"keep":{"x": [1, 2]},"drop":3},"s"]`
+	if actual := string(w.Bytes()); actual != expected {
+		t.Errorf("** Writer produced %s, wanted %s", actual, expected)
+	}
+
+	var w2 Writer
+	w2.Raw([]byte(`1`))
+	ensurePanic(t, func() { w2.Raw([]byte(`2`)) }, "tinyjson: Writer: Raw after the top-level value")
+}
+
+func TestStreamWriter(t *testing.T) {
+	var out chunkRecorder
+	w := NewStreamWriter(&out, 64)
+	w.BeginArray()
+	for i := 0; i < 100; i++ {
+		w.BeginObject()
+		w.Key("id")
+		w.Int(i)
+		w.Key("name")
+		w.String("record")
+		w.EndObject()
+	}
+	w.EndArray()
+	if len(out.chunks) < 10 {
+		t.Errorf("** only %d chunks written before Close, wanted the output to be flushed progressively", len(out.chunks))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("** Close() = %v, wanted nil", err)
+	}
+	for _, chunk := range out.chunks {
+		if len(chunk) > 64+32 {
+			t.Errorf("** chunk of %d bytes exceeds the buffer size by too much", len(chunk))
+		}
+	}
+
+	var expected Writer
+	expected.BeginArray()
+	for i := 0; i < 100; i++ {
+		expected.Value(map[string]any{"id": float64(i), "name": "record"})
+	}
+	expected.EndArray()
+	if actual := strings.Join(out.chunks, ""); actual != string(expected.Bytes()) {
+		t.Errorf("** streamed %s, wanted %s", actual, expected.Bytes())
+	}
+	if err := w.Flush(); err != nil {
+		t.Errorf("** Flush() after Close = %v, wanted nil", err)
+	}
+
+	ensurePanic(t, func() { w.Bytes() }, "tinyjson: Writer: Bytes on a stream writer")
+	w = NewStreamWriter(&out, 64)
+	w.BeginArray()
+	ensurePanic(t, func() { w.Close() }, "tinyjson: Writer: incomplete JSON")
+
+	var mem Writer
+	if err := mem.Flush(); err != nil {
+		t.Errorf("** Flush() on a non-stream Writer = %v, wanted nil", err)
+	}
+}
+
+func TestStreamWriterErrors(t *testing.T) {
+	failure := errors.New("disk on fire")
+	out := &chunkRecorder{err: failure}
+	w := NewStreamWriter(out, 8)
+	w.BeginArray()
+	for i := 0; i < 100; i++ {
+		w.String("a long enough string")
+	}
+	if err := w.Flush(); err != failure {
+		t.Errorf("** Flush() = %v, wanted %v", err, failure)
+	}
+	if len(out.chunks) != 1 {
+		t.Errorf("** %d writes attempted, wanted writing to stop after the first error", len(out.chunks))
+	}
+	if len(w.buf) != 0 {
+		t.Errorf("** %d bytes buffered after a write error, wanted them discarded", len(w.buf))
+	}
+	if err := w.Close(); err != failure {
+		t.Errorf("** Close() = %v on an incomplete document, wanted %v", err, failure)
+	}
+}
+
+type chunkRecorder struct {
+	chunks []string
+	err    error
+}
+
+func (r *chunkRecorder) Write(p []byte) (int, error) {
+	r.chunks = append(r.chunks, string(p))
+	if r.err != nil {
+		return 0, r.err
+	}
+	return len(p), nil
+}